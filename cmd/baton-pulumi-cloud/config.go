@@ -1,10 +1,17 @@
 package main
 
 import (
+	"fmt"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	"github.com/conductorone/baton-pulumi-cloud/pkg/connector"
 	"github.com/conductorone/baton-sdk/pkg/field"
 	"github.com/spf13/viper"
 )
 
+// defaultRequestsPerSecond keeps concurrent team fetching well below Pulumi's API rate limits
+const defaultRequestsPerSecond = 20
+
 var (
 	accessTokenField = field.StringField(
 		"access-token",
@@ -16,9 +23,53 @@ var (
 		field.WithRequired(true),
 		field.WithDescription("The name of the Pulumi Cloud organization"),
 	)
+	principalKeyField = field.StringField(
+		"principal-key",
+		field.WithDescription("The attribute user resources are keyed by: login or email"),
+		field.WithDefaultValue(connector.PrincipalKeyLogin),
+	)
+	scimOverrideField = field.BoolField(
+		"scim-override",
+		field.WithDescription("Allow revoking organization and team memberships that are managed by SCIM"),
+	)
+	stackIncludeTagsField = field.StringSliceField(
+		"stack-include-tags",
+		field.WithDescription("Only sync stacks with a matching tag, given as key or key=pattern, e.g. env=prod*"),
+	)
+	stackExcludeTagsField = field.StringSliceField(
+		"stack-exclude-tags",
+		field.WithDescription("Skip stacks with a matching tag, given as key or key=pattern, e.g. data-classification=public"),
+	)
+	tokenStaleDaysField = field.IntField(
+		"token-stale-days",
+		field.WithDescription("Mark access tokens unused for this many days as stale, 0 to disable"),
+		field.WithDefaultValue(connector.DefaultTokenStaleDays),
+	)
+	incrementalSyncField = field.BoolField(
+		"incremental-sync",
+		field.WithDescription("Reuse the team and stack access of the previous sync when the audit log shows no change to it since"),
+	)
+	teamFetchWorkersField = field.IntField(
+		"team-fetch-workers",
+		field.WithDescription("How many teams to fetch at once when syncing team details"),
+		field.WithDefaultValue(client.DefaultTeamFetchWorkers),
+	)
+	requestsPerSecondField = field.IntField(
+		"requests-per-second",
+		field.WithDescription("Limit on Pulumi API requests per second across all workers, 0 for no limit"),
+		field.WithDefaultValue(defaultRequestsPerSecond),
+	)
 	ConfigurationFields = []field.SchemaField{
 		accessTokenField,
 		orgNameField,
+		principalKeyField,
+		scimOverrideField,
+		stackIncludeTagsField,
+		stackExcludeTagsField,
+		tokenStaleDaysField,
+		incrementalSyncField,
+		teamFetchWorkersField,
+		requestsPerSecondField,
 	}
 
 	// FieldRelationships defines relationships between the fields listed in
@@ -33,5 +84,30 @@ var (
 // needs to perform extra validations that cannot be encoded with configuration
 // parameters.
 func ValidateConfig(v *viper.Viper) error {
+	switch principalKey := v.GetString(principalKeyField.FieldName); principalKey {
+	case "", connector.PrincipalKeyLogin, connector.PrincipalKeyEmail:
+	default:
+		return fmt.Errorf("invalid principal-key %q: must be %q or %q", principalKey, connector.PrincipalKeyLogin, connector.PrincipalKeyEmail)
+	}
+
+	if err := connector.ValidateStackTagExpressions(v.GetStringSlice(stackIncludeTagsField.FieldName)); err != nil {
+		return err
+	}
+	if err := connector.ValidateStackTagExpressions(v.GetStringSlice(stackExcludeTagsField.FieldName)); err != nil {
+		return err
+	}
+
+	if days := v.GetInt(tokenStaleDaysField.FieldName); days < 0 {
+		return fmt.Errorf("invalid %s %d: must not be negative", tokenStaleDaysField.FieldName, days)
+	}
+
+	if workers := v.GetInt(teamFetchWorkersField.FieldName); workers < 0 {
+		return fmt.Errorf("invalid %s %d: must not be negative", teamFetchWorkersField.FieldName, workers)
+	}
+
+	if rps := v.GetInt(requestsPerSecondField.FieldName); rps < 0 {
+		return fmt.Errorf("invalid %s %d: must not be negative", requestsPerSecondField.FieldName, rps)
+	}
+
 	return nil
 }
@@ -14,7 +14,113 @@ func TestConfigs(t *testing.T) {
 	)
 
 	testCases := []test.TestCase{
-		// Add test cases here.
+		{
+			Configs: map[string]string{
+				"access-token": "pul-token",
+				"org-name":     "acme",
+			},
+			IsValid: true,
+			Message: "required fields",
+		},
+		{
+			Configs: map[string]string{
+				"access-token": "pul-token",
+			},
+			IsValid: false,
+			Message: "missing org name",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":  "pul-token",
+				"org-name":      "acme",
+				"principal-key": "email",
+			},
+			IsValid: true,
+			Message: "email principal key",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":  "pul-token",
+				"org-name":      "acme",
+				"principal-key": "uuid",
+			},
+			IsValid: false,
+			Message: "unknown principal key",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":       "pul-token",
+				"org-name":           "acme",
+				"stack-include-tags": "env=prod*",
+				"stack-exclude-tags": "data-classification",
+			},
+			IsValid: true,
+			Message: "stack tag filters",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":       "pul-token",
+				"org-name":           "acme",
+				"stack-include-tags": "=prod",
+			},
+			IsValid: false,
+			Message: "stack tag filter without tag name",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":       "pul-token",
+				"org-name":           "acme",
+				"stack-exclude-tags": "env=[prod",
+			},
+			IsValid: false,
+			Message: "stack tag filter with malformed pattern",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":     "pul-token",
+				"org-name":         "acme",
+				"token-stale-days": "30",
+			},
+			IsValid: true,
+			Message: "token stale days",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":     "pul-token",
+				"org-name":         "acme",
+				"token-stale-days": "-1",
+			},
+			IsValid: false,
+			Message: "negative token stale days",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":     "pul-token",
+				"org-name":         "acme",
+				"incremental-sync": "true",
+			},
+			IsValid: true,
+			Message: "incremental sync",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":        "pul-token",
+				"org-name":            "acme",
+				"team-fetch-workers":  "16",
+				"requests-per-second": "50",
+			},
+			IsValid: true,
+			Message: "team fetch concurrency",
+		},
+		{
+			Configs: map[string]string{
+				"access-token":       "pul-token",
+				"org-name":           "acme",
+				"team-fetch-workers": "-1",
+			},
+			IsValid: false,
+			Message: "negative team fetch workers",
+		},
 	}
 
 	test.ExerciseTestCases(t, configurationSchema, ValidateConfig, testCases)
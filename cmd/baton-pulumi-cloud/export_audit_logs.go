@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/cli"
+	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	auditFormatJSON = "json"
+	auditFormatCEF  = "cef"
+)
+
+// auditCursor records how far an export got, so an interrupted export can resume where it stopped
+type auditCursor struct {
+	OrgName           string    `json:"orgName"`
+	Since             time.Time `json:"since"`
+	Until             time.Time `json:"until"`
+	Format            string    `json:"format"`
+	ContinuationToken string    `json:"continuationToken"`
+	// Offset is the size of the output file after the last exported page
+	Offset   int64 `json:"offset"`
+	Exported int   `json:"exported"`
+}
+
+// complete reports whether the export got past its last page. Only the last page leaves no
+// continuation token behind once events were exported, and an export without any events has
+// nothing to repeat.
+func (c *auditCursor) complete() bool {
+	return c.ContinuationToken == "" && c.Exported > 0
+}
+
+func (c *auditCursor) matches(other *auditCursor) bool {
+	return c.OrgName == other.OrgName && c.Since.Equal(other.Since) && c.Until.Equal(other.Until) && c.Format == other.Format
+}
+
+// resumeAuditCursor returns the cursor to export with: the saved one when it belongs to the same
+// export, or a fresh one. A cursor saved by another export means the output file holds that
+// export, which starting over would overwrite, so that is refused unless force is set.
+func resumeAuditCursor(saved, wanted *auditCursor, force bool) (*auditCursor, error) {
+	switch {
+	case saved == nil:
+		return wanted, nil
+	case saved.matches(wanted):
+		return saved, nil
+	case force:
+		return wanted, nil
+	}
+
+	return nil, fmt.Errorf(
+		"the cursor file belongs to an unfinished export of %s from %s to %s as %s; rerun with those arguments to resume it, or use --force to start over",
+		saved.OrgName, saved.Since.Format(time.RFC3339), saved.Until.Format(time.RFC3339), saved.Format,
+	)
+}
+
+// addExportAuditLogsCommand adds the export-audit-logs subcommand, which writes the organization's
+// audit log for a time window to a file without running a sync
+func addExportAuditLogsCommand(ctx context.Context, mainCMD *cobra.Command, v *viper.Viper) error {
+	cmd := &cobra.Command{
+		Use:   "export-audit-logs",
+		Short: "Export the organization audit log to a JSON Lines or CEF file",
+		Long: "Export the organization audit log for a time window, by default the previous calendar month. " +
+			"Progress is saved to a cursor file after every page, so an interrupted export resumes where it stopped when run again with the same arguments.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := v.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+			return runExportAuditLogs(cmd.Context(), v)
+		},
+	}
+	cmd.SetContext(ctx)
+
+	cmd.Flags().String("since", "", "Start of the export window, as RFC3339 or YYYY-MM-DD (default: start of the previous month)")
+	cmd.Flags().String("until", "", "End of the export window, exclusive, as RFC3339 or YYYY-MM-DD (default: start of the current month)")
+	cmd.Flags().String("format", auditFormatJSON, "Output format: json (JSON Lines) or cef")
+	cmd.Flags().String("output", "", "File the audit log is written to")
+	cmd.Flags().String("cursor-file", "", "File the export progress is saved to (default: the output file with a .cursor suffix)")
+	cmd.Flags().Bool("force", false, "Start over and overwrite the output file when it holds another export")
+
+	_, err := cli.AddCommand(mainCMD, v, &field.Configuration{
+		Fields: []field.SchemaField{accessTokenField, orgNameField},
+	}, cmd)
+	return err
+}
+
+func runExportAuditLogs(ctx context.Context, v *viper.Viper) error {
+	token := v.GetString(accessTokenField.FieldName)
+	orgName := v.GetString(orgNameField.FieldName)
+	if token == "" || orgName == "" {
+		return fmt.Errorf("%s and %s are required", accessTokenField.FieldName, orgNameField.FieldName)
+	}
+
+	output := v.GetString("output")
+	if output == "" {
+		return fmt.Errorf("output is required")
+	}
+	cursorFile := v.GetString("cursor-file")
+	if cursorFile == "" {
+		cursorFile = output + ".cursor"
+	}
+
+	format := v.GetString("format")
+	if format != auditFormatJSON && format != auditFormatCEF {
+		return fmt.Errorf("invalid format %q: must be %q or %q", format, auditFormatJSON, auditFormatCEF)
+	}
+
+	since, until, err := exportWindow(v.GetString("since"), v.GetString("until"), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	c, err := client.NewClient(token)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	cursor := &auditCursor{
+		OrgName: orgName,
+		Since:   since,
+		Until:   until,
+		Format:  format,
+	}
+	saved, err := loadAuditCursor(cursorFile)
+	if err != nil {
+		return err
+	}
+	cursor, err = resumeAuditCursor(saved, cursor, v.GetBool("force"))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer f.Close()
+
+	// Without a cursor to resume from, the export starts over and would replace the output
+	if saved == nil && !v.GetBool("force") {
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to read output file: %w", err)
+		}
+		if info.Size() > 0 {
+			return fmt.Errorf("output file %s already has content and there is no cursor to resume from; use --force to overwrite it", output)
+		}
+	}
+
+	listPage := func(token string) (*client.ListAuditLogsResponse, error) {
+		return c.ListAuditLogs(ctx, orgName, since, until, token)
+	}
+	if err := exportAuditLogPages(f, cursorFile, cursor, listPage); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d audit log events from %s to %s\n",
+		cursor.Exported, since.Format(time.RFC3339), until.Format(time.RFC3339))
+	return nil
+}
+
+// exportAuditLogPages appends the pages of the export from the cursor on to f, saving the cursor
+// before the first page and after every page including the last, and removes the cursor file
+// once the export is complete.
+// An export interrupted at any point thus resumes from its last saved page, and one interrupted
+// after its last page writes nothing twice.
+func exportAuditLogPages(f *os.File, cursorFile string, cursor *auditCursor, listPage func(token string) (*client.ListAuditLogsResponse, error)) error {
+	// Drop anything written after the last saved page, then append from there
+	if err := f.Truncate(cursor.Offset); err != nil {
+		return fmt.Errorf("failed to truncate output file: %w", err)
+	}
+	if _, err := f.Seek(cursor.Offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek output file: %w", err)
+	}
+
+	// Save the cursor before the first page too, so the output never has content without one
+	if err := saveAuditCursor(cursorFile, cursor); err != nil {
+		return err
+	}
+
+	for !cursor.complete() {
+		resp, err := listPage(cursor.ContinuationToken)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range resp.AuditLogEvents {
+			line, err := formatAuditEvent(cursor.Format, cursor.OrgName, event)
+			if err != nil {
+				return err
+			}
+			if _, err := f.WriteString(line + "\n"); err != nil {
+				return fmt.Errorf("failed to write output file: %w", err)
+			}
+		}
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("failed to seek output file: %w", err)
+		}
+		cursor.Offset = offset
+		cursor.Exported += len(resp.AuditLogEvents)
+		cursor.ContinuationToken = resp.ContinuationToken
+
+		if err := saveAuditCursor(cursorFile, cursor); err != nil {
+			return err
+		}
+		if resp.ContinuationToken == "" {
+			break
+		}
+	}
+
+	if err := os.Remove(cursorFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cursor file: %w", err)
+	}
+	return nil
+}
+
+// exportWindow parses the export window, defaulting to the calendar month before now
+func exportWindow(sinceValue, untilValue string, now time.Time) (time.Time, time.Time, error) {
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	since := startOfMonth.AddDate(0, -1, 0)
+	until := startOfMonth
+
+	var err error
+	if sinceValue != "" {
+		if since, err = parseExportTime(sinceValue); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if untilValue != "" {
+		if until, err = parseExportTime(untilValue); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+	if !since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("since (%s) must be before until (%s)", since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+
+	return since, until, nil
+}
+
+func parseExportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func loadAuditCursor(path string) (*auditCursor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cursor file: %w", err)
+	}
+
+	var cursor auditCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor file %s: %w", path, err)
+	}
+	return &cursor, nil
+}
+
+// saveAuditCursor replaces the cursor file atomically so an interruption never leaves it half written
+func saveAuditCursor(path string, cursor *auditCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cursor file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write cursor file: %w", err)
+	}
+	return nil
+}
+
+func formatAuditEvent(format, orgName string, event client.AuditLogEvent) (string, error) {
+	if format == auditFormatCEF {
+		return formatCEF(orgName, event), nil
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit log event: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatCEF renders an audit log event in ArcSight Common Event Format
+func formatCEF(orgName string, event client.AuditLogEvent) string {
+	severity := 3
+	switch {
+	case event.AuthFailure:
+		severity = 7
+	case event.ReqOrgAdmin || event.ReqStackAdmin:
+		severity = 5
+	}
+
+	extensions := []string{
+		"rt=" + strconv.FormatInt(event.Timestamp*1000, 10),
+		"suser=" + cefExtension(event.User.GithubLogin),
+		"src=" + cefExtension(event.SourceIP),
+		"msg=" + cefExtension(event.Description),
+		"cs1Label=organization",
+		"cs1=" + cefExtension(orgName),
+	}
+	if event.TokenName != "" {
+		extensions = append(extensions, "cs2Label=tokenName", "cs2="+cefExtension(event.TokenName))
+	}
+	if event.AuthFailure {
+		extensions = append(extensions, "outcome=failure")
+	}
+
+	return fmt.Sprintf("CEF:0|Pulumi|Pulumi Cloud|%s|%s|%s|%d|%s",
+		cefHeader(version),
+		cefHeader(event.Event),
+		cefHeader(event.Event),
+		severity,
+		strings.Join(extensions, " "),
+	)
+}
+
+var (
+	cefHeaderReplacer    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionReplacer = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+func cefHeader(value string) string {
+	return cefHeaderReplacer.Replace(value)
+}
+
+func cefExtension(value string) string {
+	return cefExtensionReplacer.Replace(value)
+}
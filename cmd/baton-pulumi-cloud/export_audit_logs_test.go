@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+)
+
+func TestExportWindow(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		since     string
+		until     string
+		wantSince time.Time
+		wantUntil time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "previous month by default",
+			wantSince: time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC),
+			wantUntil: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "dates",
+			since:     "2025-12-01",
+			until:     "2026-01-01",
+			wantSince: time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC),
+			wantUntil: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "RFC3339 in another zone",
+			since:     "2026-03-01T09:00:00+02:00",
+			until:     "2026-03-02T00:00:00Z",
+			wantSince: time.Date(2026, time.March, 1, 7, 0, 0, 0, time.UTC),
+			wantUntil: time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "only since",
+			since:     "2026-02-15",
+			wantSince: time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC),
+			wantUntil: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "since after until", since: "2026-03-01", until: "2026-02-01", wantErr: true},
+		{name: "empty window", since: "2026-02-01", until: "2026-02-01", wantErr: true},
+		{name: "unparseable since", since: "last month", wantErr: true},
+		{name: "unparseable until", until: "03/01/2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, until, err := exportWindow(tt.since, tt.until, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("exportWindow returned %s - %s, want an error", since, until)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("exportWindow failed: %v", err)
+			}
+			if !since.Equal(tt.wantSince) || !until.Equal(tt.wantUntil) {
+				t.Errorf("exportWindow = %s - %s, want %s - %s", since, until, tt.wantSince, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	base := client.AuditLogEvent{
+		Timestamp:   1767225600,
+		SourceIP:    "203.0.113.7",
+		Event:       "stack-deleted",
+		Description: "Deleted stack infra/prod",
+		User:        client.UserInfo{GithubLogin: "alice"},
+	}
+
+	tests := []struct {
+		name  string
+		event func(client.AuditLogEvent) client.AuditLogEvent
+		want  string
+	}{
+		{
+			name:  "plain event",
+			event: func(e client.AuditLogEvent) client.AuditLogEvent { return e },
+			want: "CEF:0|Pulumi|Pulumi Cloud|dev|stack-deleted|stack-deleted|3|" +
+				"rt=1767225600000 suser=alice src=203.0.113.7 msg=Deleted stack infra/prod cs1Label=organization cs1=acme",
+		},
+		{
+			name: "admin action with a token",
+			event: func(e client.AuditLogEvent) client.AuditLogEvent {
+				e.ReqOrgAdmin = true
+				e.TokenName = "ci"
+				return e
+			},
+			want: "CEF:0|Pulumi|Pulumi Cloud|dev|stack-deleted|stack-deleted|5|" +
+				"rt=1767225600000 suser=alice src=203.0.113.7 msg=Deleted stack infra/prod cs1Label=organization cs1=acme cs2Label=tokenName cs2=ci",
+		},
+		{
+			name: "authentication failure outranks admin",
+			event: func(e client.AuditLogEvent) client.AuditLogEvent {
+				e.ReqStackAdmin = true
+				e.AuthFailure = true
+				return e
+			},
+			want: "CEF:0|Pulumi|Pulumi Cloud|dev|stack-deleted|stack-deleted|7|" +
+				"rt=1767225600000 suser=alice src=203.0.113.7 msg=Deleted stack infra/prod cs1Label=organization cs1=acme outcome=failure",
+		},
+		{
+			name: "escaping",
+			event: func(e client.AuditLogEvent) client.AuditLogEvent {
+				e.Event = `odd|event\name`
+				e.Description = "a=b\\c\nd"
+				return e
+			},
+			want: `CEF:0|Pulumi|Pulumi Cloud|dev|odd\|event\\name|odd\|event\\name|3|` +
+				`rt=1767225600000 suser=alice src=203.0.113.7 msg=a\=b\\c\nd cs1Label=organization cs1=acme`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCEF("acme", tt.event(base)); got != tt.want {
+				t.Errorf("formatCEF =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCEFEscaping(t *testing.T) {
+	tests := []struct {
+		value         string
+		wantHeader    string
+		wantExtension string
+	}{
+		{value: "plain", wantHeader: "plain", wantExtension: "plain"},
+		{value: `a|b`, wantHeader: `a\|b`, wantExtension: `a|b`},
+		{value: `a=b`, wantHeader: `a=b`, wantExtension: `a\=b`},
+		{value: `a\b`, wantHeader: `a\\b`, wantExtension: `a\\b`},
+		{value: "a\r\nb", wantHeader: "a  b", wantExtension: `a\r\nb`},
+	}
+
+	for _, tt := range tests {
+		if got := cefHeader(tt.value); got != tt.wantHeader {
+			t.Errorf("cefHeader(%q) = %q, want %q", tt.value, got, tt.wantHeader)
+		}
+		if got := cefExtension(tt.value); got != tt.wantExtension {
+			t.Errorf("cefExtension(%q) = %q, want %q", tt.value, got, tt.wantExtension)
+		}
+	}
+}
+
+func TestResumeAuditCursor(t *testing.T) {
+	since := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	wanted := &auditCursor{OrgName: "acme", Since: since, Until: until, Format: auditFormatJSON}
+	inProgress := &auditCursor{OrgName: "acme", Since: since, Until: until, Format: auditFormatJSON, ContinuationToken: "page-3", Offset: 4096}
+	otherFormat := &auditCursor{OrgName: "acme", Since: since, Until: until, Format: auditFormatCEF, ContinuationToken: "page-3", Offset: 4096}
+	otherWindow := &auditCursor{OrgName: "acme", Since: since.AddDate(0, -1, 0), Until: since, Format: auditFormatJSON, Offset: 4096}
+
+	tests := []struct {
+		name    string
+		saved   *auditCursor
+		force   bool
+		want    *auditCursor
+		wantErr string
+	}{
+		{name: "no saved cursor", want: wanted},
+		{name: "same export resumes", saved: inProgress, want: inProgress},
+		{name: "other format refused", saved: otherFormat, wantErr: "as cef"},
+		{name: "other window refused", saved: otherWindow, wantErr: "from 2026-01-01T00:00:00Z to 2026-02-01T00:00:00Z"},
+		{name: "other export forced", saved: otherWindow, force: true, want: wanted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resumeAuditCursor(tt.saved, wanted, tt.force)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resumeAuditCursor returned %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resumeAuditCursor failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resumeAuditCursor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportAuditLogPagesResumes(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "audit.jsonl")
+	cursorFile := output + ".cursor"
+	pages := map[string]*client.ListAuditLogsResponse{
+		"": {
+			AuditLogEvents:    []client.AuditLogEvent{{Event: "user-login"}, {Event: "team-created"}},
+			ContinuationToken: "page-2",
+		},
+		"page-2": {
+			AuditLogEvents: []client.AuditLogEvent{{Event: "stack-created"}},
+		},
+	}
+
+	export := func(cursor *auditCursor, listPage func(token string) (*client.ListAuditLogsResponse, error)) error {
+		t.Helper()
+
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			t.Fatalf("failed to open output: %v", err)
+		}
+		defer f.Close()
+		return exportAuditLogPages(f, cursorFile, cursor, listPage)
+	}
+	lines := func() []string {
+		t.Helper()
+
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	// The first run is interrupted on the second page
+	err := export(&auditCursor{OrgName: "acme", Format: auditFormatJSON}, func(token string) (*client.ListAuditLogsResponse, error) {
+		if token == "page-2" {
+			return nil, errors.New("interrupted")
+		}
+		return pages[token], nil
+	})
+	if err == nil {
+		t.Fatal("interrupted export succeeded")
+	}
+
+	// The rerun resumes from the saved cursor and saves the last page before finishing
+	cursor, err := loadAuditCursor(cursorFile)
+	if err != nil || cursor == nil {
+		t.Fatalf("no cursor saved after the first page: %v", err)
+	}
+	if cursor.ContinuationToken != "page-2" || cursor.Exported != 2 {
+		t.Errorf("saved cursor = %+v, want page-2 after 2 events", cursor)
+	}
+	err = export(cursor, func(token string) (*client.ListAuditLogsResponse, error) {
+		if token != "page-2" {
+			t.Errorf("resumed export fetched page %q, want page-2", token)
+		}
+		return pages[token], nil
+	})
+	if err != nil {
+		t.Fatalf("resumed export failed: %v", err)
+	}
+	if got := lines(); len(got) != 3 {
+		t.Errorf("output has %d events, want 3: %v", len(got), got)
+	}
+	if _, err := os.Stat(cursorFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("cursor file left behind after a complete export: %v", err)
+	}
+
+	// A run killed after saving the last page but before removing its cursor is complete, so the
+	// rerun writes nothing twice
+	if err := saveAuditCursor(cursorFile, cursor); err != nil {
+		t.Fatalf("failed to save cursor: %v", err)
+	}
+	err = export(cursor, func(token string) (*client.ListAuditLogsResponse, error) {
+		t.Errorf("complete export fetched page %q", token)
+		return pages[token], nil
+	})
+	if err != nil {
+		t.Fatalf("rerun of a complete export failed: %v", err)
+	}
+	if got := lines(); len(got) != 3 {
+		t.Errorf("output has %d events after the rerun, want 3: %v", len(got), got)
+	}
+	if _, err := os.Stat(cursorFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("cursor file left behind after rerunning a complete export: %v", err)
+	}
+}
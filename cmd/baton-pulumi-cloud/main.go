@@ -21,7 +21,7 @@ var version = "dev"
 func main() {
 	ctx := context.Background()
 
-	_, cmd, err := config.DefineConfiguration(
+	v, cmd, err := config.DefineConfiguration(
 		ctx,
 		"baton-pulumi-cloud",
 		getConnector,
@@ -36,6 +36,12 @@ func main() {
 
 	cmd.Version = version
 
+	err = addExportAuditLogsCommand(ctx, cmd, v)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
 	err = cmd.Execute()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
@@ -46,15 +52,30 @@ func main() {
 func getConnector(ctx context.Context, cfg *viper.Viper) (types.ConnectorServer, error) {
 	l := ctxzap.Extract(ctx)
 
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+
 	token := cfg.GetString("access-token")
 	orgName := cfg.GetString("org-name")
 
-	c, err := client.NewClient(token)
+	c, err := client.NewClient(token, client.WithRateLimit(cfg.GetInt("requests-per-second")))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
-	cb, err := connector.New(ctx, c, orgName)
+	cb, err := connector.New(
+		ctx,
+		c,
+		orgName,
+		connector.WithPrincipalKey(cfg.GetString("principal-key")),
+		connector.WithSCIMOverride(cfg.GetBool("scim-override")),
+		connector.WithProvisioning(cfg.GetBool("provisioning")),
+		connector.WithStackTagFilter(cfg.GetStringSlice("stack-include-tags"), cfg.GetStringSlice("stack-exclude-tags")),
+		connector.WithTokenStaleDays(cfg.GetInt("token-stale-days")),
+		connector.WithTeamFetchWorkers(cfg.GetInt("team-fetch-workers")),
+		connector.WithIncrementalSync(cfg.GetBool("incremental-sync")),
+	)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
@@ -63,5 +84,6 @@ func getConnector(ctx context.Context, cfg *viper.Viper) (types.ConnectorServer,
 	if err != nil {
 		return nil, err
 	}
-	return connector, nil
+
+	return &syncScopedConnector{ConnectorServer: connector, connector: cb}, nil
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/types"
+)
+
+// syncScopedConnector ends the connector's sync when the SDK cleans up after one, dropping the
+// state kept for it and expiring the responses cached during it. Cleanup is the only call the
+// SDK makes once every resource of a sync has been fetched.
+type syncScopedConnector struct {
+	types.ConnectorServer
+	connector *connector.Connector
+}
+
+func (s *syncScopedConnector) Cleanup(ctx context.Context, req *v2.ConnectorServiceCleanupRequest) (*v2.ConnectorServiceCleanupResponse, error) {
+	resp, err := s.ConnectorServer.Cleanup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.connector.FinishSync()
+
+	return resp, nil
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxAssetSize bounds the size of a downloaded asset, avatars are typically a few kilobytes
+	maxAssetSize = 1 << 20
+	// maxAssetCacheSize bounds the memory held by downloaded assets
+	maxAssetCacheSize = 16 << 20
+)
+
+type asset struct {
+	url         string
+	contentType string
+	data        []byte
+}
+
+// assetCache keeps downloaded assets up to maxAssetCacheSize bytes, dropping the least recently
+// used ones first
+type assetCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newAssetCache() *assetCache {
+	return &assetCache{
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (a *assetCache) get(assetURL string) (*asset, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	element, ok := a.entries[assetURL]
+	if !ok {
+		return nil, false
+	}
+	a.order.MoveToFront(element)
+	return element.Value.(*asset), true
+}
+
+func (a *assetCache) put(cached *asset) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if element, ok := a.entries[cached.url]; ok {
+		a.size -= len(element.Value.(*asset).data)
+		a.order.Remove(element)
+	}
+	a.entries[cached.url] = a.order.PushFront(cached)
+	a.size += len(cached.data)
+
+	for a.size > maxAssetCacheSize {
+		oldest := a.order.Back()
+		evicted := a.order.Remove(oldest).(*asset)
+		delete(a.entries, evicted.url)
+		a.size -= len(evicted.data)
+	}
+}
+
+// GetAsset downloads an image such as an avatar, returning its content type and data.
+// The access token is not sent, since assets are usually hosted outside of Pulumi. The response
+// is read through a limit, so an oversized asset is never held in memory, and downloaded assets
+// are cached up to maxAssetCacheSize bytes.
+func (c *Client) GetAsset(ctx context.Context, assetURL string) (string, []byte, error) {
+	if cached, ok := c.assets.get(assetURL); ok {
+		return cached.contentType, cached.data, nil
+	}
+
+	reqURL, err := url.Parse(assetURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse asset URL: %w", err)
+	}
+	if reqURL.Scheme != "https" {
+		return "", nil, fmt.Errorf("refusing to fetch asset over %q", reqURL.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The request skips send, which reads whole bodies into memory
+	if c.limiter != nil {
+		c.limiter.Take()
+	}
+	resp, err := c.baseHttpClient.HttpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", nil, status.Error(codes.DeadlineExceeded, "request timeout")
+		}
+		return "", nil, fmt.Errorf("failed to get asset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		code := statusCodeError(resp.StatusCode)
+		if code == codes.OK {
+			code = codes.Unknown
+		}
+		return "", nil, status.Errorf(code, "failed to get asset: unexpected status code: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxAssetSize {
+		return "", nil, fmt.Errorf("asset is too large: %d bytes", resp.ContentLength)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read asset: %w", err)
+	}
+	if len(data) > maxAssetSize {
+		return "", nil, fmt.Errorf("asset is larger than %d bytes", maxAssetSize)
+	}
+
+	contentType, err := detectImageContentType(resp.Header.Get("Content-Type"), data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.assets.put(&asset{url: assetURL, contentType: contentType, data: data})
+
+	return contentType, data, nil
+}
+
+// detectImageContentType prefers the declared content type and falls back to sniffing the data,
+// rejecting anything that isn't an image
+func detectImageContentType(declared string, data []byte) (string, error) {
+	contentType := ""
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		contentType = mediaType
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			contentType = mediaType
+		}
+	}
+
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("asset is not an image: %s", contentType)
+	}
+
+	return contentType, nil
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func TestGetAsset(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/avatar.png":
+			w.Write(pngHeader)
+		case "/huge.png":
+			// Streamed without a Content-Length, so only the read limit stops it
+			w.Write(pngHeader)
+			chunk := bytes.Repeat([]byte{0}, 64<<10)
+			for i := 0; i < 2*maxAssetSize/len(chunk); i++ {
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+				w.(http.Flusher).Flush()
+			}
+		case "/page.html":
+			fmt.Fprint(w, "<html></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewClient("pul-test")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	c.baseHttpClient.HttpClient = server.Client()
+	ctx := context.Background()
+
+	contentType, data, err := c.GetAsset(ctx, server.URL+"/avatar.png")
+	if err != nil {
+		t.Fatalf("GetAsset failed: %v", err)
+	}
+	if contentType != "image/png" || !bytes.Equal(data, pngHeader) {
+		t.Errorf("GetAsset = %s, %q, want the PNG", contentType, data)
+	}
+	if _, _, err := c.GetAsset(ctx, server.URL+"/avatar.png"); err != nil {
+		t.Fatalf("GetAsset failed from the cache: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("avatar fetched %d times, want it cached", n)
+	}
+
+	for _, path := range []string{"/huge.png", "/page.html", "/missing.png"} {
+		if _, _, err := c.GetAsset(ctx, server.URL+path); err == nil {
+			t.Errorf("GetAsset(%s) succeeded, want an error", path)
+		}
+	}
+	if _, _, err := c.GetAsset(ctx, "http://example.com/avatar.png"); err == nil {
+		t.Error("GetAsset fetched an asset over plain HTTP")
+	}
+}
+
+func TestAssetCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	a := newAssetCache()
+	data := make([]byte, maxAssetSize)
+	for i := 0; i < maxAssetCacheSize/maxAssetSize; i++ {
+		a.put(&asset{url: fmt.Sprintf("https://example.com/%d", i), data: data})
+	}
+
+	// Using the oldest entry keeps it over the next oldest
+	if _, ok := a.get("https://example.com/0"); !ok {
+		t.Fatal("entry 0 missing before the cache is full")
+	}
+	a.put(&asset{url: "https://example.com/new", data: data})
+
+	if _, ok := a.get("https://example.com/0"); !ok {
+		t.Error("recently used entry was evicted")
+	}
+	if _, ok := a.get("https://example.com/1"); ok {
+		t.Error("least recently used entry was kept past the size limit")
+	}
+	if a.size > maxAssetCacheSize {
+		t.Errorf("cache holds %d bytes, want at most %d", a.size, maxAssetCacheSize)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// AuditLogEvent represents an entry of the organization audit log
+type AuditLogEvent struct {
+	Timestamp   int64    `json:"timestamp"`
+	SourceIP    string   `json:"sourceIP"`
+	Event       string   `json:"event"`
+	Description string   `json:"description"`
+	User        UserInfo `json:"user"`
+	TokenID     string   `json:"tokenID,omitempty"`
+	TokenName   string   `json:"tokenName,omitempty"`
+	// ReqOrgAdmin and ReqStackAdmin are set for actions that required admin rights
+	ReqOrgAdmin   bool `json:"reqOrgAdmin,omitempty"`
+	ReqStackAdmin bool `json:"reqStackAdmin,omitempty"`
+	AuthFailure   bool `json:"authFailure,omitempty"`
+}
+
+// ListAuditLogsResponse represents the paginated response from listing audit log events
+type ListAuditLogsResponse struct {
+	AuditLogEvents    []AuditLogEvent `json:"auditLogEvents"`
+	ContinuationToken string          `json:"continuationToken,omitempty"`
+}
+
+// ListAuditLogs returns a page of the audit log events recorded in [startTime, endTime)
+func (c *Client) ListAuditLogs(ctx context.Context, orgName string, startTime, endTime time.Time, continuationToken string) (*ListAuditLogsResponse, error) {
+	queryParams := url.Values{}
+	queryParams.Set("startTime", strconv.FormatInt(startTime.Unix(), 10))
+	queryParams.Set("endTime", strconv.FormatInt(endTime.Unix(), 10))
+	if continuationToken != "" {
+		queryParams.Set("continuationToken", continuationToken)
+	}
+
+	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/auditlogs/v2", orgName), queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var response ListAuditLogsResponse
+	resp, err := c.send(req, uhttp.WithJSONResponse(&response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return &response, nil
+}
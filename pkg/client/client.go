@@ -1,11 +1,21 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"go.uber.org/ratelimit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Client represents a Pulumi API client
@@ -13,11 +23,47 @@ type Client struct {
 	baseHttpClient *uhttp.BaseHttpClient
 	baseURL        *url.URL
 	token          string
+
+	// limiter is shared by every request, whichever path it takes
+	limiter ratelimit.Limiter
+
+	assets    *assetCache
+	responses *responseCache
+}
+
+// Option configures optional client behavior
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	requestsPerSecond int
+	baseURL           string
+}
+
+// WithRateLimit limits the client to requestsPerSecond requests, shared by every caller of the
+// client including concurrent team prefetching. Zero means no limit.
+func WithRateLimit(requestsPerSecond int) Option {
+	return func(o *clientOptions) {
+		o.requestsPerSecond = requestsPerSecond
+	}
+}
+
+// WithBaseURL sets the URL of the Pulumi Cloud API, https://api.pulumi.com by default
+func WithBaseURL(baseURL string) Option {
+	return func(o *clientOptions) {
+		o.baseURL = baseURL
+	}
 }
 
 // NewClient creates a new Pulumi API client
-func NewClient(token string) (*Client, error) {
-	baseURL, err := url.Parse("https://api.pulumi.com")
+func NewClient(token string, opts ...Option) (*Client, error) {
+	options := clientOptions{
+		baseURL: "https://api.pulumi.com",
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	baseURL, err := url.Parse(options.baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse base URL: %w", err)
 	}
@@ -27,23 +73,33 @@ func NewClient(token string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
 
-	wrapper, err := uhttp.NewBaseHttpClientWithContext(context.Background(), httpClient)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP client wrapper: %w", err)
-	}
-
-	return &Client{
-		baseHttpClient: wrapper,
+	// The wrapper is built without uhttp's response cache: it keys GETs by URL alone, so it would
+	// answer the If-None-Match revalidations of the client's own cache without asking Pulumi.
+	c := &Client{
+		baseHttpClient: &uhttp.BaseHttpClient{HttpClient: httpClient},
 		baseURL:        baseURL,
 		token:          token,
-	}, nil
+		assets:         newAssetCache(),
+		responses:      newResponseCache(),
+	}
+	if options.requestsPerSecond > 0 {
+		c.limiter = ratelimit.New(options.requestsPerSecond, ratelimit.Per(time.Second))
+	}
+
+	return c, nil
 }
 
 // User represents a Pulumi user/member
 type UserInfo struct {
+	ID          string `json:"id,omitempty"`
 	Name        string `json:"name"`
 	GithubLogin string `json:"githubLogin"`
 	AvatarUrl   string `json:"avatarUrl"`
+	// Email is only returned when the token is allowed to see member emails
+	Email      string   `json:"email,omitempty"`
+	Identities []string `json:"identities,omitempty"`
+	// IsMachineUser is set for the non-human users backing organization and team tokens
+	IsMachineUser bool `json:"isMachineUser,omitempty"`
 }
 
 type User struct {
@@ -52,6 +108,13 @@ type User struct {
 	Created       string   `json:"created"`
 	KnownToPulumi bool     `json:"knownToPulumi"`
 	VirtualAdmin  bool     `json:"virtualAdmin"`
+	LastLogin     string   `json:"lastLogin,omitempty"`
+	// Suspended is set when SSO has suspended the member's access
+	Suspended bool `json:"suspended,omitempty"`
+	// Deprovisioned is set when SCIM has deprovisioned the member
+	Deprovisioned bool `json:"deprovisioned,omitempty"`
+	// ScimManaged is set when the member was provisioned by SCIM
+	ScimManaged bool `json:"scimManaged,omitempty"`
 }
 
 // Team represents a Pulumi team
@@ -62,6 +125,105 @@ type Team struct {
 	Description string     `json:"description"`
 	Members     []UserInfo `json:"members"`
 	UserRole    string     `json:"userRole"`
+	// Stacks is only returned when getting a single team
+	Stacks []TeamStackPermission `json:"stacks,omitempty"`
+	// Role is the organization role assigned to the team, if any
+	Role *TeamRole `json:"role,omitempty"`
+}
+
+// TeamRole is an organization role assigned to a team
+type TeamRole struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	DefaultIdentifier string `json:"defaultIdentifier,omitempty"`
+}
+
+// IsAdmin reports whether the team holds the built-in organization admin role
+func (t *Team) IsAdmin() bool {
+	if t.Role == nil {
+		return false
+	}
+	return t.Role.DefaultIdentifier == "admin" || strings.EqualFold(t.Role.Name, "admin")
+}
+
+// Organization represents a Pulumi organization
+type Organization struct {
+	GithubLogin string `json:"githubLogin"`
+	Name        string `json:"name"`
+	AvatarUrl   string `json:"avatarUrl"`
+}
+
+// OrganizationSettings represents the settings of a Pulumi organization
+type OrganizationSettings struct {
+	SCIMEnabled bool `json:"scimEnabled"`
+}
+
+// OrganizationInfo represents an organization the token owner belongs to
+type OrganizationInfo struct {
+	GithubLogin string `json:"githubLogin"`
+	Name        string `json:"name"`
+	AvatarUrl   string `json:"avatarUrl"`
+}
+
+// TokenInfo describes a non-personal access token
+type TokenInfo struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+	Team         string `json:"team"`
+}
+
+// CurrentUser represents the identity that owns the access token
+type CurrentUser struct {
+	ID            string             `json:"id"`
+	GithubLogin   string             `json:"githubLogin"`
+	Name          string             `json:"name"`
+	Email         string             `json:"email"`
+	AvatarUrl     string             `json:"avatarUrl"`
+	Organizations []OrganizationInfo `json:"organizations"`
+	TokenInfo     *TokenInfo         `json:"tokenInfo,omitempty"`
+}
+
+const (
+	TokenTypePersonal     = "personal"
+	TokenTypeTeam         = "team"
+	TokenTypeOrganization = "organization"
+)
+
+// TokenType returns the kind of access token used to authenticate
+func (u *CurrentUser) TokenType() string {
+	switch {
+	case u.TokenInfo == nil:
+		return TokenTypePersonal
+	case u.TokenInfo.Team != "":
+		return TokenTypeTeam
+	case u.TokenInfo.Organization != "":
+		return TokenTypeOrganization
+	default:
+		return TokenTypePersonal
+	}
+}
+
+// OrganizationNames returns the logins of the organizations the token can access
+func (u *CurrentUser) OrganizationNames() []string {
+	if u.TokenInfo != nil && u.TokenInfo.Organization != "" {
+		return []string{u.TokenInfo.Organization}
+	}
+
+	names := make([]string, 0, len(u.Organizations))
+	for _, org := range u.Organizations {
+		names = append(names, org.GithubLogin)
+	}
+	return names
+}
+
+// CanAccessOrg reports whether the token can access the given organization
+func (u *CurrentUser) CanAccessOrg(orgName string) bool {
+	for _, name := range u.OrganizationNames() {
+		if strings.EqualFold(name, orgName) {
+			return true
+		}
+	}
+	return false
 }
 
 // ListUsersResponse represents the paginated response from listing users
@@ -91,6 +253,181 @@ func (c *Client) requestOptions(body interface{}) []uhttp.RequestOption {
 	return options
 }
 
+// FinishSync ends a sync, so that the responses cached during it go stale. Responses also go
+// stale after responseMaxAge, for syncs that fail before they finish.
+func (c *Client) FinishSync() {
+	c.responses.expire()
+}
+
+// getJSON gets a JSON resource into response. Responses are cached for the rest of the sync, up
+// to responseMaxAge, and revalidated with their ETag afterwards. Reads with an Uncached context
+// skip the cache.
+func (c *Client) getJSON(ctx context.Context, path string, queryParams url.Values, response interface{}) error {
+	if isUncached(ctx) {
+		return c.getJSONUncached(ctx, path, queryParams, response)
+	}
+
+	reqURL, err := c.buildURL(path, queryParams)
+	if err != nil {
+		return err
+	}
+
+	key := reqURL.String()
+	cached, ok := c.responses.get(key)
+	if ok && cached.fresh {
+		return json.Unmarshal(cached.body, response)
+	}
+
+	options := c.requestOptions(nil)
+	if ok {
+		options = append(options, uhttp.WithHeader("If-None-Match", cached.etag))
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, options...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var wrapped uhttp.WrapperResponse
+	resp, err := c.send(req, func(r *uhttp.WrapperResponse) error {
+		wrapped = *r
+		if r.StatusCode == http.StatusNotModified {
+			return nil
+		}
+		return uhttp.WithJSONResponse(response)(r)
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+
+	body, etag := wrapped.Body, wrapped.Header.Get("ETag")
+	switch {
+	case ok && wrapped.StatusCode == http.StatusNotModified:
+		body, etag = cached.body, cached.etag
+		if err := json.Unmarshal(body, response); err != nil {
+			return fmt.Errorf("failed to unmarshal cached json response: %w", err)
+		}
+	case err != nil:
+		return err
+	}
+
+	c.responses.put(key, body, etag)
+
+	return nil
+}
+
+type uncachedKey struct{}
+
+// Uncached returns a context whose reads skip every cache and go to Pulumi, for callers that
+// must see the current state, such as actions checking the effect of their own changes. Their
+// responses are not cached either, so the rest of the sync keeps a consistent view.
+func Uncached(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncachedKey{}, true)
+}
+
+func isUncached(ctx context.Context) bool {
+	uncached, _ := ctx.Value(uncachedKey{}).(bool)
+	return uncached
+}
+
+// getJSONUncached gets a JSON resource into response straight from Pulumi
+func (c *Client) getJSONUncached(ctx context.Context, path string, queryParams url.Values, response interface{}) error {
+	reqURL, err := c.buildURL(path, queryParams)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req, uhttp.WithJSONResponse(response))
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+
+	return err
+}
+
+// send sends a request once the rate limiter allows it and applies options to the response.
+// Failures map to the same gRPC codes as with uhttp.
+func (c *Client) send(req *http.Request, options ...uhttp.DoOption) (*http.Response, error) {
+	if c.limiter != nil {
+		c.limiter.Take()
+	}
+
+	resp, err := c.baseHttpClient.HttpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.Error(codes.DeadlineExceeded, "request timeout")
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to read response: %v", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	wrapped := uhttp.WrapperResponse{
+		Header:     resp.Header,
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	var errs []error
+	for _, option := range options {
+		if err := option(&wrapped); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if code := statusCodeError(resp.StatusCode); code != codes.OK {
+		if code == codes.Unknown {
+			errs = append(errs, fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+		}
+		return resp, uhttp.WrapErrorsWithRateLimitInfo(code, resp, errs...)
+	}
+
+	return resp, errors.Join(errs...)
+}
+
+// statusCodeError returns the gRPC code uhttp reports for an HTTP status, or OK for success.
+// Not Modified counts as success since it answers a conditional request, and Payment Required,
+// which Pulumi returns for features outside the organization's plan, counts as PermissionDenied
+// like the Forbidden returned for features outside the token's reach.
+func statusCodeError(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusNotModified:
+		return codes.OK
+	case http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden, http.StatusPaymentRequired:
+		return codes.PermissionDenied
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	}
+
+	switch {
+	case statusCode >= 500:
+		return codes.Unavailable
+	case statusCode < 200 || statusCode >= 300:
+		return codes.Unknown
+	}
+	return codes.OK
+}
+
 // buildURL creates a full URL for a given path and query parameters
 func (c *Client) buildURL(path string, queryParams url.Values) (*url.URL, error) {
 	reqURL, err := url.Parse(fmt.Sprintf("/api/%s", path))
@@ -106,74 +443,74 @@ func (c *Client) buildURL(path string, queryParams url.Values) (*url.URL, error)
 	return reqURL, nil
 }
 
-// ListUsers returns a list of all users in the organization
-func (c *Client) ListUsers(ctx context.Context, orgName string, continuationToken string) (*ListUsersResponse, error) {
-	queryParams := url.Values{}
-	queryParams.Set("type", "backend")
-	if continuationToken != "" {
-		queryParams.Set("continuationToken", continuationToken)
+// GetCurrentUser returns the identity that owns the access token
+func (c *Client) GetCurrentUser(ctx context.Context) (*CurrentUser, error) {
+	var user CurrentUser
+	err := c.getJSON(ctx, "user", nil, &user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
 	}
 
-	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/members", orgName), queryParams)
+	return &user, nil
+}
+
+// GetOrganization returns details about the organization
+func (c *Client) GetOrganization(ctx context.Context, orgName string) (*Organization, error) {
+	var org Organization
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s", orgName), nil, &org)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get organization: %w", err)
 	}
 
-	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	return &org, nil
+}
+
+// GetOrganizationSettings returns the settings of the organization
+func (c *Client) GetOrganizationSettings(ctx context.Context, orgName string) (*OrganizationSettings, error) {
+	var settings OrganizationSettings
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/settings", orgName), nil, &settings)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to get organization settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// ListUsers returns a list of all users in the organization
+func (c *Client) ListUsers(ctx context.Context, orgName string, continuationToken string) (*ListUsersResponse, error) {
+	queryParams := url.Values{}
+	queryParams.Set("type", "backend")
+	if continuationToken != "" {
+		queryParams.Set("continuationToken", continuationToken)
 	}
 
 	var response ListUsersResponse
-	resp, err := c.baseHttpClient.Do(req, uhttp.WithJSONResponse(&response))
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/members", orgName), queryParams, &response)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
-	defer resp.Body.Close()
 
 	return &response, nil
 }
 
 // ListTeams returns a list of all teams in the organization
 func (c *Client) ListTeams(ctx context.Context, orgName string) ([]Team, error) {
-	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/teams", orgName), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
 	var response ListTeamsResponse
-	resp, err := c.baseHttpClient.Do(req, uhttp.WithJSONResponse(&response))
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/teams", orgName), nil, &response)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list teams: %w", err)
 	}
-	defer resp.Body.Close()
 
 	return response.Teams, nil
 }
 
 // GetTeam returns details about a specific team including its members
 func (c *Client) GetTeam(ctx context.Context, orgName, teamName string) (*Team, error) {
-	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/teams/%s", orgName, teamName), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
 	var team Team
-	resp, err := c.baseHttpClient.Do(req, uhttp.WithJSONResponse(&team))
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/teams/%s", orgName, teamName), nil, &team)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get team: %w", err)
 	}
-	defer resp.Body.Close()
 
 	return &team, nil
 }
@@ -190,7 +527,7 @@ func (c *Client) RemoveUser(ctx context.Context, orgName, username string) error
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.baseHttpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return fmt.Errorf("failed to remove user: %w", err)
 	}
@@ -215,7 +552,7 @@ func (c *Client) UpdateUserRole(ctx context.Context, orgName, username, role str
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.baseHttpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return fmt.Errorf("failed to update user role: %w", err)
 	}
@@ -241,7 +578,7 @@ func (c *Client) UpdateTeamMembership(ctx context.Context, orgName, teamName, us
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.baseHttpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return fmt.Errorf("failed to update team membership: %w", err)
 	}
@@ -249,3 +586,14 @@ func (c *Client) UpdateTeamMembership(ctx context.Context, orgName, teamName, us
 
 	return nil
 }
+
+// IsNotFound reports whether a request failed because the resource does not exist
+func IsNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+// IsPermissionDenied reports whether a request failed because the token may not read the resource,
+// or the organization's plan does not include it
+func IsPermissionDenied(err error) bool {
+	return status.Code(err) == codes.PermissionDenied
+}
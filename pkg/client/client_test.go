@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+// newTestClient returns a client for an API served by handler
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient("pul-test", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, body)
+}
+
+func TestUncachedReadsSkipCaches(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/stacks/acme/infra/prod" {
+			http.NotFound(w, r)
+			return
+		}
+		n := requests.Add(1)
+		writeJSON(w, fmt.Sprintf(`{"orgName":"acme","projectName":"infra","stackName":"prod","activeUpdate":"update-%d"}`, n))
+	}))
+	ctx := context.Background()
+
+	cached, err := c.GetStack(ctx, "acme", "infra", "prod")
+	if err != nil {
+		t.Fatalf("GetStack failed: %v", err)
+	}
+	if _, err := c.GetStack(ctx, "acme", "infra", "prod"); err != nil {
+		t.Fatalf("GetStack failed: %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("cached reads sent %d requests, want 1", got)
+	}
+
+	current, err := c.GetStack(Uncached(ctx), "acme", "infra", "prod")
+	if err != nil {
+		t.Fatalf("uncached GetStack failed: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("uncached read sent %d requests in total, want 2", got)
+	}
+	if current.ActiveUpdate != "update-2" {
+		t.Errorf("uncached read returned %q, want update-2", current.ActiveUpdate)
+	}
+
+	// The rest of the sync keeps seeing the cached response
+	again, err := c.GetStack(ctx, "acme", "infra", "prod")
+	if err != nil {
+		t.Fatalf("GetStack failed: %v", err)
+	}
+	if again.ActiveUpdate != cached.ActiveUpdate {
+		t.Errorf("cached read returned %q after an uncached read, want %q", again.ActiveUpdate, cached.ActiveUpdate)
+	}
+}
+
+func TestUncachedReadErrors(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/stacks/acme/infra/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	ctx := Uncached(context.Background())
+
+	if _, err := c.GetStack(ctx, "acme", "infra", "missing"); !IsNotFound(err) {
+		t.Errorf("missing stack returned %v, want a not found error", err)
+	}
+	if _, err := c.GetStack(ctx, "acme", "infra", "forbidden"); !IsPermissionDenied(err) {
+		t.Errorf("forbidden stack returned %v, want a permission denied error", err)
+	}
+}
+
+func TestStatusCodeError(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       codes.Code
+	}{
+		{http.StatusOK, codes.OK},
+		{http.StatusNoContent, codes.OK},
+		{http.StatusNotModified, codes.OK},
+		{http.StatusBadRequest, codes.Unknown},
+		{http.StatusUnauthorized, codes.Unauthenticated},
+		{http.StatusPaymentRequired, codes.PermissionDenied},
+		{http.StatusForbidden, codes.PermissionDenied},
+		{http.StatusNotFound, codes.NotFound},
+		{http.StatusRequestTimeout, codes.DeadlineExceeded},
+		{http.StatusConflict, codes.AlreadyExists},
+		{http.StatusTooManyRequests, codes.Unavailable},
+		{http.StatusInternalServerError, codes.Unavailable},
+		{http.StatusNotImplemented, codes.Unimplemented},
+		{http.StatusBadGateway, codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		if got := statusCodeError(tt.statusCode); got != tt.want {
+			t.Errorf("statusCodeError(%d) = %v, want %v", tt.statusCode, got, tt.want)
+		}
+	}
+}
+
+func TestRevalidatesWithETag(t *testing.T) {
+	var requests atomic.Int32
+	var revalidations atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writeJSON(w, `{"name":"acme","githubLogin":"acme"}`)
+	}))
+	ctx := context.Background()
+
+	for sync := 0; sync < 3; sync++ {
+		org, err := c.GetOrganization(ctx, "acme")
+		if err != nil {
+			t.Fatalf("GetOrganization failed in sync %d: %v", sync, err)
+		}
+		if org.Name != "acme" {
+			t.Errorf("GetOrganization returned %q in sync %d, want acme", org.Name, sync)
+		}
+		if _, err := c.GetOrganization(ctx, "acme"); err != nil {
+			t.Fatalf("GetOrganization failed in sync %d: %v", sync, err)
+		}
+		c.FinishSync()
+	}
+
+	// One request per sync, the later ones revalidating the first response
+	if got := requests.Load(); got != 3 {
+		t.Errorf("sent %d requests, want 3", got)
+	}
+	if got := revalidations.Load(); got != 2 {
+		t.Errorf("sent %d revalidations, want 2", got)
+	}
+}
+
+func TestRefetchesWithoutETag(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("request %d revalidated a response without an ETag", n)
+		}
+		writeJSON(w, fmt.Sprintf(`{"name":"acme-%d"}`, n))
+	}))
+	ctx := context.Background()
+
+	if _, err := c.GetOrganization(ctx, "acme"); err != nil {
+		t.Fatalf("GetOrganization failed: %v", err)
+	}
+	c.FinishSync()
+
+	org, err := c.GetOrganization(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetOrganization failed: %v", err)
+	}
+	if org.Name != "acme-2" {
+		t.Errorf("GetOrganization returned %q after the sync ended, want acme-2", org.Name)
+	}
+}
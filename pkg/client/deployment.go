@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// AgentPool represents a self-hosted deployment runner pool
+type AgentPool struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Created     int64            `json:"created"`
+	Status      string           `json:"status,omitempty"`
+	LastSeen    int64            `json:"lastSeen,omitempty"`
+	Tokens      []AgentPoolToken `json:"tokens,omitempty"`
+}
+
+// AgentPoolToken represents an access token agents use to join a pool
+type AgentPoolToken struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Created     int64  `json:"created"`
+	LastUsed    int64  `json:"lastUsed,omitempty"`
+	Expires     int64  `json:"expires,omitempty"`
+	CreatedBy   string `json:"createdBy,omitempty"`
+}
+
+// ListAgentPoolsResponse represents the response from listing agent pools
+type ListAgentPoolsResponse struct {
+	AgentPools []AgentPool `json:"agentPools"`
+}
+
+// DeploymentSettings represents the deployment configuration of a stack
+type DeploymentSettings struct {
+	SourceContext    *DeploymentSourceContext    `json:"sourceContext,omitempty"`
+	GitHub           *DeploymentGitHubSettings   `json:"gitHub,omitempty"`
+	OperationContext *DeploymentOperationContext `json:"operationContext,omitempty"`
+	AgentPoolID      string                      `json:"agentPoolID,omitempty"`
+}
+
+// DeploymentSourceContext describes where a deployment gets its program from
+type DeploymentSourceContext struct {
+	Git *DeploymentGitSource `json:"git,omitempty"`
+}
+
+// DeploymentGitSource describes a git repository used as deployment source
+type DeploymentGitSource struct {
+	RepoURL string `json:"repoURL,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	RepoDir string `json:"repoDir,omitempty"`
+}
+
+// DeploymentGitHubSettings describes the GitHub app integration of a stack
+type DeploymentGitHubSettings struct {
+	Repository string `json:"repository,omitempty"`
+}
+
+// DeploymentOperationContext describes the environment deployments run in
+type DeploymentOperationContext struct {
+	OIDC *DeploymentOIDC `json:"oidc,omitempty"`
+}
+
+// DeploymentOIDC describes the cloud providers deployments federate with
+type DeploymentOIDC struct {
+	AWS   *DeploymentOIDCProvider `json:"aws,omitempty"`
+	Azure *DeploymentOIDCProvider `json:"azure,omitempty"`
+	GCP   *DeploymentOIDCProvider `json:"gcp,omitempty"`
+}
+
+// DeploymentOIDCProvider describes the identity a deployment assumes in a cloud provider
+type DeploymentOIDCProvider struct {
+	RoleARN        string `json:"roleArn,omitempty"`
+	ClientID       string `json:"clientId,omitempty"`
+	TenantID       string `json:"tenantId,omitempty"`
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	ProjectID      string `json:"projectId,omitempty"`
+}
+
+// ListAgentPools returns the deployment agent pools of the organization
+func (c *Client) ListAgentPools(ctx context.Context, orgName string) ([]AgentPool, error) {
+	var response ListAgentPoolsResponse
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/agent-pools", orgName), nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent pools: %w", err)
+	}
+
+	return response.AgentPools, nil
+}
+
+// GetAgentPool returns details about an agent pool including its tokens
+func (c *Client) GetAgentPool(ctx context.Context, orgName, poolID string) (*AgentPool, error) {
+	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/agent-pools/%s", orgName, poolID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var pool AgentPool
+	resp, err := c.send(req, uhttp.WithJSONResponse(&pool))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent pool: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return &pool, nil
+}
+
+// GetDeploymentSettings returns the deployment settings of a stack, or nil if none are configured
+// or the organization or token has no access to Deployments
+func (c *Client) GetDeploymentSettings(ctx context.Context, orgName, projectName, stackName string) (*DeploymentSettings, error) {
+	var settings DeploymentSettings
+	err := c.getJSON(ctx, fmt.Sprintf("stacks/%s/%s/%s/deployments/settings", orgName, projectName, stackName), nil, &settings)
+	if err != nil {
+		if IsNotFound(err) || IsPermissionDenied(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get deployment settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// PauseStackDeployments stops queued and new deployments of a stack from running
+func (c *Client) PauseStackDeployments(ctx context.Context, orgName, projectName, stackName string) error {
+	return c.stackRequest(ctx, "POST", orgName, projectName, stackName, "deployments/pause", nil, "pause stack deployments")
+}
+
+// ResumeStackDeployments lets deployments of a stack run again after PauseStackDeployments
+func (c *Client) ResumeStackDeployments(ctx context.Context, orgName, projectName, stackName string) error {
+	return c.stackRequest(ctx, "POST", orgName, projectName, stackName, "deployments/resume", nil, "resume stack deployments")
+}
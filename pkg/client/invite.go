@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// InviteUser sends an invite to join the organization with the given role. Pulumi adds the user
+// to the teams once they accept the invite.
+func (c *Client) InviteUser(ctx context.Context, orgName, email, role string, teams []string) error {
+	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/invites", orgName), nil)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"email": email,
+		"role":  role,
+	}
+	if len(teams) > 0 {
+		body["teams"] = teams
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "POST", reqURL, c.requestOptions(body)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to invite user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
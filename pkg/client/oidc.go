@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// OIDCIssuer represents an OIDC issuer trusted to exchange its tokens for Pulumi access tokens
+type OIDCIssuer struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	URL           string   `json:"url"`
+	Issuer        string   `json:"issuer"`
+	Thumbprints   []string `json:"thumbprints,omitempty"`
+	Created       string   `json:"created,omitempty"`
+	Modified      string   `json:"modified,omitempty"`
+	MaxExpiration int64    `json:"maxExpiration,omitempty"`
+}
+
+// ListOIDCIssuersResponse represents the response from listing OIDC issuers
+type ListOIDCIssuersResponse struct {
+	OIDCIssuers []OIDCIssuer `json:"oidcIssuers"`
+}
+
+// AuthPolicy represents the authorization policy attached to an OIDC issuer
+type AuthPolicy struct {
+	ID       string           `json:"id"`
+	Policies []AuthPolicyRule `json:"policies"`
+}
+
+// AuthPolicyRule decides which token a federated identity matching the claim rules receives
+type AuthPolicyRule struct {
+	Decision              string            `json:"decision"`
+	TokenType             string            `json:"tokenType"`
+	TeamName              string            `json:"teamName,omitempty"`
+	UserLogin             string            `json:"userLogin,omitempty"`
+	AuthorizedPermissions []string          `json:"authorizedPermissions,omitempty"`
+	Rules                 map[string]string `json:"rules,omitempty"`
+}
+
+// ListOIDCIssuers returns the OIDC issuers registered with the organization
+func (c *Client) ListOIDCIssuers(ctx context.Context, orgName string) ([]OIDCIssuer, error) {
+	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/oidc/issuers", orgName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var response ListOIDCIssuersResponse
+	resp, err := c.send(req, uhttp.WithJSONResponse(&response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OIDC issuers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return response.OIDCIssuers, nil
+}
+
+// GetOIDCIssuerPolicy returns the authorization policy of an OIDC issuer
+func (c *Client) GetOIDCIssuerPolicy(ctx context.Context, orgName, issuerID string) (*AuthPolicy, error) {
+	var policy AuthPolicy
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/auth/policies/oidcissuers/%s", orgName, issuerID), nil, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OIDC issuer policy: %w", err)
+	}
+
+	return &policy, nil
+}
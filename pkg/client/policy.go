@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// PolicyPack represents a CrossGuard policy pack published to the organization
+type PolicyPack struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Versions    []int    `json:"versions"`
+	VersionTags []string `json:"versionTags"`
+}
+
+// ListPolicyPacksResponse represents the response from listing policy packs
+type ListPolicyPacksResponse struct {
+	PolicyPacks []PolicyPack `json:"policyPacks"`
+}
+
+// PolicyGroupSummary represents a policy group as returned when listing
+type PolicyGroupSummary struct {
+	Name                  string `json:"name"`
+	IsOrgDefault          bool   `json:"isOrgDefault"`
+	NumStacks             int    `json:"numStacks"`
+	NumEnabledPolicyPacks int    `json:"numEnabledPolicyPacks"`
+}
+
+// ListPolicyGroupsResponse represents the response from listing policy groups
+type ListPolicyGroupsResponse struct {
+	PolicyGroups []PolicyGroupSummary `json:"policyGroups"`
+}
+
+// PolicyGroup represents a policy group with its stacks and applied policy packs
+type PolicyGroup struct {
+	Name               string              `json:"name"`
+	IsOrgDefault       bool                `json:"isOrgDefault"`
+	Stacks             []PolicyGroupStack  `json:"stacks"`
+	AppliedPolicyPacks []AppliedPolicyPack `json:"appliedPolicyPacks"`
+}
+
+// PolicyGroupStack identifies a stack in a policy group
+type PolicyGroupStack struct {
+	Name           string `json:"name"`
+	RoutingProject string `json:"routingProject"`
+}
+
+// AppliedPolicyPack represents a policy pack version enforced by a policy group
+type AppliedPolicyPack struct {
+	Name        string                            `json:"name"`
+	DisplayName string                            `json:"displayName"`
+	Version     int                               `json:"version"`
+	VersionTag  string                            `json:"versionTag"`
+	Config      map[string]map[string]interface{} `json:"config,omitempty"`
+}
+
+// EnforcementLevel returns the enforcement level configured for all policies of the pack,
+// defaulting to advisory when none is set
+func (p AppliedPolicyPack) EnforcementLevel() string {
+	if all, ok := p.Config["all"]; ok {
+		if level, ok := all["enforcementLevel"].(string); ok && level != "" {
+			return level
+		}
+	}
+	return "advisory"
+}
+
+// ListPolicyPacks returns the policy packs published to the organization
+func (c *Client) ListPolicyPacks(ctx context.Context, orgName string) ([]PolicyPack, error) {
+	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/policypacks", orgName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var response ListPolicyPacksResponse
+	resp, err := c.send(req, uhttp.WithJSONResponse(&response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy packs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return response.PolicyPacks, nil
+}
+
+// ListPolicyGroups returns the policy groups of the organization
+func (c *Client) ListPolicyGroups(ctx context.Context, orgName string) ([]PolicyGroupSummary, error) {
+	var response ListPolicyGroupsResponse
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/policygroups", orgName), nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy groups: %w", err)
+	}
+
+	return response.PolicyGroups, nil
+}
+
+// GetPolicyGroup returns a policy group with its stacks and applied policy packs
+func (c *Client) GetPolicyGroup(ctx context.Context, orgName, groupName string) (*PolicyGroup, error) {
+	var group PolicyGroup
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/policygroups/%s", orgName, groupName), nil, &group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy group: %w", err)
+	}
+
+	return &group, nil
+}
+
+// AddStackToPolicyGroup adds a stack to a policy group
+func (c *Client) AddStackToPolicyGroup(ctx context.Context, orgName, groupName, projectName, stackName string) error {
+	return c.updatePolicyGroup(ctx, orgName, groupName, map[string]interface{}{
+		"addStack": PolicyGroupStack{Name: stackName, RoutingProject: projectName},
+	})
+}
+
+// RemoveStackFromPolicyGroup removes a stack from a policy group
+func (c *Client) RemoveStackFromPolicyGroup(ctx context.Context, orgName, groupName, projectName, stackName string) error {
+	return c.updatePolicyGroup(ctx, orgName, groupName, map[string]interface{}{
+		"removeStack": PolicyGroupStack{Name: stackName, RoutingProject: projectName},
+	})
+}
+
+func (c *Client) updatePolicyGroup(ctx context.Context, orgName, groupName string, body map[string]interface{}) error {
+	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/policygroups/%s", orgName, groupName), nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "PATCH", reqURL, c.requestOptions(body)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to update policy group: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
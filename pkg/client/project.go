@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// Project represents a Pulumi project
+type Project struct {
+	OrgName     string  `json:"orgName"`
+	RepoName    string  `json:"repoName"`
+	Name        string  `json:"name"`
+	Runtime     string  `json:"runtime,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Stacks      []Stack `json:"stacks"`
+}
+
+// Repo represents a source repository and the projects it contains
+type Repo struct {
+	OrgName  string    `json:"orgName"`
+	Name     string    `json:"name"`
+	Projects []Project `json:"projects"`
+}
+
+// ListReposResponse represents the response from listing repositories
+type ListReposResponse struct {
+	Repos []Repo `json:"repos"`
+}
+
+// ListProjects returns the projects of the organization across all repositories
+func (c *Client) ListProjects(ctx context.Context, orgName string) ([]Project, error) {
+	reqURL, err := c.buildURL(fmt.Sprintf("console/orgs/%s/repos", orgName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var response ListReposResponse
+	resp, err := c.send(req, uhttp.WithJSONResponse(&response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var projects []Project
+	for _, repo := range response.Repos {
+		for _, project := range repo.Projects {
+			if project.RepoName == "" {
+				project.RepoName = repo.Name
+			}
+			projects = append(projects, project)
+		}
+	}
+
+	return projects, nil
+}
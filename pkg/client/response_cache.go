@@ -0,0 +1,81 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// responseMaxAge is how long a cached response is used without asking Pulumi. Syncs end with
+// FinishSync, but a failed or aborted sync may never get there, so entries also go stale on their
+// own and a later sync or action revalidates them instead of reading old data.
+const responseMaxAge = 10 * time.Minute
+
+// responseCache keeps the GET responses of a sync keyed by URL, so that builders asking for the
+// same page share one request. Once a sync ends, or responseMaxAge after they were fetched,
+// entries go stale: those with an ETag are revalidated with If-None-Match on next use, the others
+// are fetched again.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	now     func() time.Time
+}
+
+// cachedResponse is a response body along with the ETag Pulumi sent for it
+type cachedResponse struct {
+	body []byte
+	etag string
+	// fresh is set when the response was fetched or revalidated during the current sync, and
+	// validated is when that happened
+	fresh     bool
+	validated time.Time
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		entries: make(map[string]*cachedResponse),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached response for a URL
+func (r *responseCache) get(key string) (cachedResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+
+	cached := *entry
+	cached.fresh = entry.fresh && r.now().Sub(entry.validated) < responseMaxAge
+	return cached, true
+}
+
+// put caches a response fetched or revalidated during the current sync
+func (r *responseCache) put(key string, body []byte, etag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[key] = &cachedResponse{
+		body:      body,
+		etag:      etag,
+		fresh:     true,
+		validated: r.now(),
+	}
+}
+
+// expire makes every entry stale. Entries that cannot be revalidated, or that were not used
+// since the previous expiry, are dropped so the cache only holds what the last sync needed.
+func (r *responseCache) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, entry := range r.entries {
+		if !entry.fresh || entry.etag == "" {
+			delete(r.entries, key)
+			continue
+		}
+		entry.fresh = false
+	}
+}
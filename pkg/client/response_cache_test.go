@@ -0,0 +1,59 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseCacheExpire(t *testing.T) {
+	r := newResponseCache()
+	r.put("etag", []byte("a"), `"a"`)
+	r.put("no-etag", []byte("b"), "")
+	r.put("unused", []byte("c"), `"c"`)
+
+	r.expire()
+
+	entry, ok := r.get("etag")
+	if !ok || entry.fresh || entry.etag != `"a"` || string(entry.body) != "a" {
+		t.Errorf("entry with an ETag = %+v, %v, want it kept as stale", entry, ok)
+	}
+	if _, ok := r.get("no-etag"); ok {
+		t.Error("entry without an ETag was kept")
+	}
+
+	// Revalidating marks the entry fresh again, while the untouched one goes
+	r.put("etag", []byte("a"), `"a"`)
+	r.expire()
+
+	if _, ok := r.get("etag"); !ok {
+		t.Error("entry used during the sync was dropped")
+	}
+	if _, ok := r.get("unused"); ok {
+		t.Error("entry unused for a whole sync was kept")
+	}
+}
+
+func TestResponseCacheMaxAge(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	r := newResponseCache()
+	r.now = func() time.Time { return now }
+	r.put("etag", []byte("a"), `"a"`)
+
+	now = now.Add(responseMaxAge - time.Second)
+	if entry, ok := r.get("etag"); !ok || !entry.fresh {
+		t.Errorf("entry = %+v, %v, want it fresh before its max age", entry, ok)
+	}
+
+	// Without FinishSync, e.g. after a failed sync, entries still go stale with age
+	now = now.Add(time.Second)
+	entry, ok := r.get("etag")
+	if !ok || entry.fresh || string(entry.body) != "a" {
+		t.Errorf("entry = %+v, %v, want it kept as stale past its max age", entry, ok)
+	}
+
+	// Revalidating makes it fresh again
+	r.put("etag", []byte("a"), `"a"`)
+	if entry, ok := r.get("etag"); !ok || !entry.fresh {
+		t.Errorf("entry = %+v, %v, want it fresh once revalidated", entry, ok)
+	}
+}
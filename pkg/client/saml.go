@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// SAMLConfig represents the SAML SSO configuration of an organization
+type SAMLConfig struct {
+	Enabled         bool              `json:"enabled"`
+	EntityID        string            `json:"entityId"`
+	SSOURL          string            `json:"ssoUrl"`
+	GroupsAttribute string            `json:"groupsAttribute,omitempty"`
+	TeamMappings    []SAMLTeamMapping `json:"teamMappings,omitempty"`
+}
+
+// SAMLTeamMapping maps identity provider groups to a Pulumi team
+type SAMLTeamMapping struct {
+	Team   string   `json:"team"`
+	Groups []string `json:"groups"`
+}
+
+// GroupsForTeam returns the identity provider groups mapped to a team
+func (s *SAMLConfig) GroupsForTeam(teamName string) []string {
+	if s == nil || !s.Enabled {
+		return nil
+	}
+
+	var groups []string
+	for _, mapping := range s.TeamMappings {
+		if mapping.Team == teamName {
+			groups = append(groups, mapping.Groups...)
+		}
+	}
+	return groups
+}
+
+// GetSAMLConfig returns the SAML configuration of the organization, or nil if SAML isn't configured
+func (c *Client) GetSAMLConfig(ctx context.Context, orgName string) (*SAMLConfig, error) {
+	var config SAMLConfig
+	err := c.getJSON(ctx, fmt.Sprintf("orgs/%s/saml", orgName), nil, &config)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get SAML config: %w", err)
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Stack represents a Pulumi stack summary
+type Stack struct {
+	OrgName       string `json:"orgName"`
+	ProjectName   string `json:"projectName"`
+	StackName     string `json:"stackName"`
+	LastUpdate    int64  `json:"lastUpdate,omitempty"`
+	ResourceCount int    `json:"resourceCount,omitempty"`
+	// Tags are only returned when getting a single stack
+	Tags map[string]string `json:"tags,omitempty"`
+	// ActiveUpdate is the ID of the update in progress, only returned when getting a single stack
+	ActiveUpdate string `json:"activeUpdate,omitempty"`
+}
+
+// ListStacksResponse represents the paginated response from listing stacks
+type ListStacksResponse struct {
+	Stacks            []Stack `json:"stacks"`
+	ContinuationToken string  `json:"continuationToken,omitempty"`
+}
+
+// ListStacks returns a page of the stacks in the organization
+func (c *Client) ListStacks(ctx context.Context, orgName string, continuationToken string) (*ListStacksResponse, error) {
+	return c.ListProjectStacks(ctx, orgName, "", continuationToken)
+}
+
+// ListProjectStacks returns a page of the stacks in a project, or in the whole organization if projectName is empty
+func (c *Client) ListProjectStacks(ctx context.Context, orgName, projectName string, continuationToken string) (*ListStacksResponse, error) {
+	queryParams := url.Values{}
+	queryParams.Set("organization", orgName)
+	if projectName != "" {
+		queryParams.Set("project", projectName)
+	}
+	if continuationToken != "" {
+		queryParams.Set("continuationToken", continuationToken)
+	}
+
+	var response ListStacksResponse
+	err := c.getJSON(ctx, "user/stacks", queryParams, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	return &response, nil
+}
+
+// GetStack returns details about a stack including its tags
+func (c *Client) GetStack(ctx context.Context, orgName, projectName, stackName string) (*Stack, error) {
+	var stack Stack
+	err := c.getJSON(ctx, fmt.Sprintf("stacks/%s/%s/%s", orgName, projectName, stackName), nil, &stack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack: %w", err)
+	}
+
+	return &stack, nil
+}
+
+// CancelStackUpdate requests cancellation of the update in progress on a stack. The update
+// stops asynchronously, so callers should poll GetStack until ActiveUpdate is empty.
+func (c *Client) CancelStackUpdate(ctx context.Context, orgName, projectName, stackName string) error {
+	return c.stackRequest(ctx, "POST", orgName, projectName, stackName, "cancel", nil, "cancel stack update")
+}
+
+// TransferStack moves a stack to another organization, keeping its project and stack names
+func (c *Client) TransferStack(ctx context.Context, orgName, projectName, stackName, toOrgName string) error {
+	body := map[string]string{
+		"toOrg": toOrgName,
+	}
+	return c.stackRequest(ctx, "POST", orgName, projectName, stackName, "transfer", body, "transfer stack")
+}
+
+// RenameStack moves a stack to another project within its organization and renames it
+func (c *Client) RenameStack(ctx context.Context, orgName, projectName, stackName, newProjectName, newStackName string) error {
+	body := map[string]string{
+		"newName":    newStackName,
+		"newProject": newProjectName,
+	}
+	return c.stackRequest(ctx, "POST", orgName, projectName, stackName, "rename", body, "rename stack")
+}
+
+// SetStackTag sets a tag on a stack
+func (c *Client) SetStackTag(ctx context.Context, orgName, projectName, stackName, name, value string) error {
+	body := map[string]string{
+		"name":  name,
+		"value": value,
+	}
+	return c.stackRequest(ctx, "POST", orgName, projectName, stackName, "tags", body, "set stack tag")
+}
+
+// DeleteStackTag removes a tag from a stack
+func (c *Client) DeleteStackTag(ctx context.Context, orgName, projectName, stackName, name string) error {
+	return c.stackRequest(ctx, "DELETE", orgName, projectName, stackName, "tags/"+url.PathEscape(name), nil, "delete stack tag")
+}
+
+func (c *Client) stackRequest(ctx context.Context, method, orgName, projectName, stackName, path string, body interface{}, action string) error {
+	reqURL, err := c.buildURL(fmt.Sprintf("stacks/%s/%s/%s/%s", orgName, projectName, stackName, path), nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, method, reqURL, c.requestOptions(body)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
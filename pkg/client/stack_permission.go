@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// Stack permission levels used by both team and collaborator permissions
+const (
+	StackPermissionNone  = 0
+	StackPermissionRead  = 101
+	StackPermissionWrite = 102
+	StackPermissionAdmin = 103
+)
+
+// TeamStackPermission is a team's permission on a stack, returned when getting a team
+type TeamStackPermission struct {
+	ProjectName string `json:"projectName"`
+	StackName   string `json:"stackName"`
+	Permission  int    `json:"permission"`
+}
+
+// StackCollaborator is a user granted direct access to a stack
+type StackCollaborator struct {
+	User       UserInfo `json:"user"`
+	Permission int      `json:"permission"`
+}
+
+type listStackCollaboratorsResponse struct {
+	Collaborators []StackCollaborator `json:"collaborators"`
+}
+
+// ListStackCollaborators returns the users with direct permissions on a stack
+func (c *Client) ListStackCollaborators(ctx context.Context, orgName, projectName, stackName string) ([]StackCollaborator, error) {
+	var response listStackCollaboratorsResponse
+	err := c.getJSON(ctx, fmt.Sprintf("stacks/%s/%s/%s/collaborators", orgName, projectName, stackName), nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stack collaborators: %w", err)
+	}
+
+	return response.Collaborators, nil
+}
+
+// SetStackCollaborator grants a user direct permission on a stack, replacing any existing permission
+func (c *Client) SetStackCollaborator(ctx context.Context, orgName, projectName, stackName, username string, permission int) error {
+	reqURL, err := c.buildURL(fmt.Sprintf("stacks/%s/%s/%s/collaborators/%s", orgName, projectName, stackName, username), nil)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]int{
+		"permission": permission,
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "PUT", reqURL, c.requestOptions(body)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to set stack collaborator: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// RemoveStackCollaborator removes a user's direct permission on a stack
+func (c *Client) RemoveStackCollaborator(ctx context.Context, orgName, projectName, stackName, username string) error {
+	reqURL, err := c.buildURL(fmt.Sprintf("stacks/%s/%s/%s/collaborators/%s", orgName, projectName, stackName, username), nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "DELETE", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to remove stack collaborator: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// SetTeamStackPermission grants a team permission on a stack, replacing any existing permission
+func (c *Client) SetTeamStackPermission(ctx context.Context, orgName, teamName, projectName, stackName string, permission int) error {
+	return c.updateTeam(ctx, orgName, teamName, map[string]interface{}{
+		"addStackPermission": TeamStackPermission{
+			ProjectName: projectName,
+			StackName:   stackName,
+			Permission:  permission,
+		},
+	})
+}
+
+// RemoveTeamStackPermission removes a team's permission on a stack
+func (c *Client) RemoveTeamStackPermission(ctx context.Context, orgName, teamName, projectName, stackName string) error {
+	return c.updateTeam(ctx, orgName, teamName, map[string]interface{}{
+		"removeStack": map[string]string{
+			"projectName": projectName,
+			"stackName":   stackName,
+		},
+	})
+}
+
+func (c *Client) updateTeam(ctx context.Context, orgName, teamName string, body map[string]interface{}) error {
+	reqURL, err := c.buildURL(fmt.Sprintf("orgs/%s/teams/%s", orgName, teamName), nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "PATCH", reqURL, c.requestOptions(body)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to update team: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
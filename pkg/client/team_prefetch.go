@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultTeamFetchWorkers is how many team details a TeamPrefetcher fetches at once by default
+const DefaultTeamFetchWorkers = 8
+
+// TeamPrefetcher fetches the details of every team in an organization concurrently, and keeps
+// them until Reset so that a sync gets each team once without a request per lookup. Requests
+// still go through the client, so they share its rate limiter.
+type TeamPrefetcher struct {
+	client  *Client
+	orgName string
+	workers int
+
+	mu     sync.Mutex
+	teams  map[string]*Team
+	errs   map[string]error
+	names  []string
+	loaded bool
+}
+
+// NewTeamPrefetcher returns a prefetcher fetching up to workers teams at once, or
+// DefaultTeamFetchWorkers if workers is zero
+func (c *Client) NewTeamPrefetcher(orgName string, workers int) *TeamPrefetcher {
+	if workers < 1 {
+		workers = DefaultTeamFetchWorkers
+	}
+
+	return &TeamPrefetcher{
+		client:  c,
+		orgName: orgName,
+		workers: workers,
+	}
+}
+
+// Reset drops the fetched teams, so that the next lookup fetches them again
+func (p *TeamPrefetcher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.teams = nil
+	p.errs = nil
+	p.names = nil
+	p.loaded = false
+}
+
+// GetTeam returns the details of a team, fetching every team on first use. A team that failed to
+// fetch returns its own error, and teams created since the prefetch are fetched individually.
+func (p *TeamPrefetcher) GetTeam(ctx context.Context, teamName string) (*Team, error) {
+	if err := p.load(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	team, ok := p.teams[teamName]
+	err, failed := p.errs[teamName]
+	p.mu.Unlock()
+	if ok {
+		return team, nil
+	}
+	if failed {
+		return nil, err
+	}
+
+	team, err = p.client.GetTeam(ctx, p.orgName, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.teams[teamName] = team
+	p.mu.Unlock()
+
+	return team, nil
+}
+
+// ListTeams returns the details of every team, in the order the API lists them. Teams deleted
+// since they were listed are left out, and any other team that failed to fetch fails the list.
+func (p *TeamPrefetcher) ListTeams(ctx context.Context) ([]*Team, error) {
+	if err := p.load(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for _, err := range p.errs {
+		if !IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	teams := make([]*Team, 0, len(p.names))
+	for _, name := range p.names {
+		teams = append(teams, p.teams[name])
+	}
+
+	return teams, nil
+}
+
+// load fetches every team unless they have been fetched since the last Reset. Lookups made
+// while the fetch runs wait for it. A team that fails to fetch, for instance because it was
+// deleted since it was listed, doesn't stop the others: its error is kept for its lookups.
+func (p *TeamPrefetcher) load(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.loaded {
+		return nil
+	}
+
+	summaries, err := p.client.ListTeams(ctx, p.orgName)
+	if err != nil {
+		return err
+	}
+
+	names := make(chan int)
+	teams := make([]*Team, len(summaries))
+	errs := make([]error, len(summaries))
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(p.workers, len(summaries)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range names {
+				teams[i], errs[i] = p.client.GetTeam(ctx, p.orgName, summaries[i].Name)
+			}
+		}()
+	}
+
+feed:
+	for i := range summaries {
+		select {
+		case names <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(names)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.teams = make(map[string]*Team, len(summaries))
+	p.errs = make(map[string]error)
+	p.names = make([]string, 0, len(summaries))
+	for i, summary := range summaries {
+		if errs[i] != nil {
+			p.errs[summary.Name] = fmt.Errorf("failed to prefetch team %s: %w", summary.Name, errs[i])
+			continue
+		}
+		p.teams[summary.Name] = teams[i]
+		p.names = append(p.names, summary.Name)
+	}
+	p.loaded = true
+
+	return nil
+}
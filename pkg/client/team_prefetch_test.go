@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// teamAPI serves a team list and team details, failing the teams listed in failures with their
+// status code, and records how many team details are fetched at once
+type teamAPI struct {
+	names    []string
+	failures map[string]int
+
+	mu          sync.Mutex
+	fetched     map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func newTeamAPI(names []string, failures map[string]int) *teamAPI {
+	return &teamAPI{
+		names:    names,
+		failures: failures,
+		fetched:  make(map[string]int),
+	}
+}
+
+func (a *teamAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/orgs/acme/teams" {
+		a.mu.Lock()
+		summaries := make([]string, 0, len(a.names))
+		for _, name := range a.names {
+			summaries = append(summaries, fmt.Sprintf(`{"name":%q}`, name))
+		}
+		a.mu.Unlock()
+		writeJSON(w, `{"teams":[`+strings.Join(summaries, ",")+`]}`)
+		return
+	}
+
+	name, ok := strings.CutPrefix(r.URL.Path, "/api/orgs/acme/teams/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	a.mu.Lock()
+	a.fetched[name]++
+	a.inFlight++
+	a.maxInFlight = max(a.maxInFlight, a.inFlight)
+	a.mu.Unlock()
+
+	// Hold the request so that concurrent fetches overlap
+	time.Sleep(20 * time.Millisecond)
+
+	a.mu.Lock()
+	a.inFlight--
+	a.mu.Unlock()
+
+	if code, ok := a.failures[name]; ok {
+		w.WriteHeader(code)
+		return
+	}
+	writeJSON(w, fmt.Sprintf(`{"name":%q,"members":[{"githubLogin":"%s-lead"}]}`, name, name))
+}
+
+// fetches returns how many times the details of a team were fetched
+func (a *teamAPI) fetches(name string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.fetched[name]
+}
+
+// concurrency returns the most team details fetched at once
+func (a *teamAPI) concurrency() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.maxInFlight
+}
+
+func TestTeamPrefetcherFetchesConcurrently(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}
+	api := newTeamAPI(names, nil)
+	p := newTestClient(t, api).NewTeamPrefetcher("acme", 3)
+	ctx := context.Background()
+
+	// Concurrent lookups share a single prefetch
+	var wg sync.WaitGroup
+	var failures atomic.Int32
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			team, err := p.GetTeam(ctx, name)
+			if err != nil || team.Name != name {
+				failures.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := failures.Load(); n != 0 {
+		t.Fatalf("%d lookups failed", n)
+	}
+	for _, name := range names {
+		if n := api.fetches(name); n != 1 {
+			t.Errorf("team %s fetched %d times, want 1", name, n)
+		}
+	}
+	maxInFlight := api.concurrency()
+	if maxInFlight > 3 {
+		t.Errorf("%d teams fetched at once, want at most 3 workers", maxInFlight)
+	}
+	if maxInFlight < 2 {
+		t.Errorf("teams were fetched one at a time, want up to 3 at once")
+	}
+
+	teams, err := p.ListTeams(ctx)
+	if err != nil {
+		t.Fatalf("ListTeams failed: %v", err)
+	}
+	var got []string
+	for _, team := range teams {
+		got = append(got, team.Name)
+	}
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Errorf("ListTeams = %v, want %v in API order", got, names)
+	}
+}
+
+func TestTeamPrefetcherKeepsPerTeamErrors(t *testing.T) {
+	api := newTeamAPI([]string{"a", "gone", "broken", "b"}, map[string]int{
+		"gone":   http.StatusNotFound,
+		"broken": http.StatusInternalServerError,
+	})
+	p := newTestClient(t, api).NewTeamPrefetcher("acme", 2)
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b"} {
+		if _, err := p.GetTeam(ctx, name); err != nil {
+			t.Errorf("GetTeam(%s) failed next to failing teams: %v", name, err)
+		}
+	}
+	if _, err := p.GetTeam(ctx, "gone"); !IsNotFound(err) {
+		t.Errorf("GetTeam(gone) = %v, want a not found error", err)
+	}
+	_, err := p.GetTeam(ctx, "broken")
+	if err == nil || IsNotFound(err) {
+		t.Errorf("GetTeam(broken) = %v, want its fetch error", err)
+	}
+
+	// Failed teams are not fetched again on lookup
+	if n := api.fetches("broken"); n != 1 {
+		t.Errorf("team broken fetched %d times, want 1", n)
+	}
+
+	if _, err := p.ListTeams(ctx); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("ListTeams = %v, want the error of team broken", err)
+	}
+}
+
+func TestTeamPrefetcherSkipsDeletedTeams(t *testing.T) {
+	api := newTeamAPI([]string{"a", "gone", "b"}, map[string]int{"gone": http.StatusNotFound})
+	p := newTestClient(t, api).NewTeamPrefetcher("acme", 0)
+
+	teams, err := p.ListTeams(context.Background())
+	if err != nil {
+		t.Fatalf("ListTeams failed: %v", err)
+	}
+	if len(teams) != 2 || teams[0].Name != "a" || teams[1].Name != "b" {
+		t.Errorf("ListTeams = %v, want teams a and b", teams)
+	}
+}
+
+func TestTeamPrefetcherFetchesNewTeams(t *testing.T) {
+	api := newTeamAPI([]string{"a"}, nil)
+	p := newTestClient(t, api).NewTeamPrefetcher("acme", 0)
+	ctx := context.Background()
+
+	if _, err := p.GetTeam(ctx, "a"); err != nil {
+		t.Fatalf("GetTeam failed: %v", err)
+	}
+
+	// A team created after the prefetch is fetched on its own, without a new prefetch
+	team, err := p.GetTeam(ctx, "new")
+	if err != nil {
+		t.Fatalf("GetTeam(new) failed: %v", err)
+	}
+	if team.Name != "new" {
+		t.Errorf("GetTeam(new) = %s, want new", team.Name)
+	}
+	if n := api.fetches("a"); n != 1 {
+		t.Errorf("team a fetched %d times, want 1", n)
+	}
+
+	// Reset makes the next lookup prefetch again
+	p.Reset()
+	p.client.FinishSync()
+	if _, err := p.GetTeam(ctx, "a"); err != nil {
+		t.Fatalf("GetTeam failed after Reset: %v", err)
+	}
+	if n := api.fetches("a"); n != 2 {
+		t.Errorf("team a fetched %d times after Reset, want 2", n)
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// AccessToken represents a personal, team or organization access token
+type AccessToken struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description"`
+	Created     int64  `json:"created"`
+	LastUsed    int64  `json:"lastUsed,omitempty"`
+	Expires     int64  `json:"expires,omitempty"`
+	// CreatedBy is the login of the user who created a team or organization token
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+type listAccessTokensResponse struct {
+	Tokens []AccessToken `json:"tokens"`
+}
+
+// ListOrgTokens returns the organization access tokens
+func (c *Client) ListOrgTokens(ctx context.Context, orgName string) ([]AccessToken, error) {
+	return c.listTokens(ctx, fmt.Sprintf("orgs/%s/tokens", orgName))
+}
+
+// ListTeamTokens returns the access tokens of a team
+func (c *Client) ListTeamTokens(ctx context.Context, orgName, teamName string) ([]AccessToken, error) {
+	return c.listTokens(ctx, fmt.Sprintf("orgs/%s/teams/%s/tokens", orgName, teamName))
+}
+
+// ListPersonalTokens returns the personal access tokens of the user owning the access token.
+// Pulumi does not let anyone else list a user's personal tokens.
+func (c *Client) ListPersonalTokens(ctx context.Context) ([]AccessToken, error) {
+	return c.listTokens(ctx, "user/tokens")
+}
+
+// DeletePersonalToken deletes a personal access token of the user owning the access token
+func (c *Client) DeletePersonalToken(ctx context.Context, tokenID string) error {
+	return c.deleteToken(ctx, fmt.Sprintf("user/tokens/%s", tokenID))
+}
+
+// DeleteOrgToken deletes an organization access token
+func (c *Client) DeleteOrgToken(ctx context.Context, orgName, tokenID string) error {
+	return c.deleteToken(ctx, fmt.Sprintf("orgs/%s/tokens/%s", orgName, tokenID))
+}
+
+// DeleteTeamToken deletes an access token of a team
+func (c *Client) DeleteTeamToken(ctx context.Context, orgName, teamName, tokenID string) error {
+	return c.deleteToken(ctx, fmt.Sprintf("orgs/%s/teams/%s/tokens/%s", orgName, teamName, tokenID))
+}
+
+func (c *Client) listTokens(ctx context.Context, path string) ([]AccessToken, error) {
+	reqURL, err := c.buildURL(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var response listAccessTokensResponse
+	resp, err := c.send(req, uhttp.WithJSONResponse(&response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return response.Tokens, nil
+}
+
+func (c *Client) deleteToken(ctx context.Context, path string) error {
+	reqURL, err := c.buildURL(path, nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "DELETE", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
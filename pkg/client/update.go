@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// StackUpdate represents an entry of a stack's update history
+type StackUpdate struct {
+	Info        UpdateInfo `json:"info"`
+	Version     int        `json:"version"`
+	RequestedBy UserInfo   `json:"requestedBy"`
+}
+
+// UpdateInfo describes what an update did and when. Kind is update, preview, refresh, destroy or import.
+type UpdateInfo struct {
+	Kind      string `json:"kind"`
+	StartTime int64  `json:"startTime"`
+	EndTime   int64  `json:"endTime,omitempty"`
+	Result    string `json:"result"`
+	Message   string `json:"message,omitempty"`
+}
+
+// ListStackUpdatesResponse represents a page of a stack's update history
+type ListStackUpdatesResponse struct {
+	Updates      []StackUpdate `json:"updates"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Total        int           `json:"total"`
+}
+
+// ListStackUpdates returns a page of a stack's update history, newest first. Pages start at 1.
+func (c *Client) ListStackUpdates(ctx context.Context, orgName, projectName, stackName string, page, pageSize int) (*ListStackUpdatesResponse, error) {
+	queryParams := url.Values{}
+	queryParams.Set("output-type", "service")
+	queryParams.Set("page", strconv.Itoa(page))
+	queryParams.Set("pageSize", strconv.Itoa(pageSize))
+
+	reqURL, err := c.buildURL(fmt.Sprintf("stacks/%s/%s/%s/updates", orgName, projectName, stackName), queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var response ListStackUpdatesResponse
+	resp, err := c.send(req, uhttp.WithJSONResponse(&response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stack updates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return &response, nil
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// Webhook represents an organization or stack webhook
+type Webhook struct {
+	Name             string   `json:"name"`
+	DisplayName      string   `json:"displayName"`
+	PayloadURL       string   `json:"payloadUrl"`
+	Format           string   `json:"format"`
+	Filters          []string `json:"filters,omitempty"`
+	Active           bool     `json:"active"`
+	OrganizationName string   `json:"organizationName"`
+	ProjectName      string   `json:"projectName,omitempty"`
+	StackName        string   `json:"stackName,omitempty"`
+}
+
+// ListOrgWebhooks returns the webhooks registered on the organization
+func (c *Client) ListOrgWebhooks(ctx context.Context, orgName string) ([]Webhook, error) {
+	return c.listWebhooks(ctx, fmt.Sprintf("orgs/%s/hooks", orgName))
+}
+
+// ListStackWebhooks returns the webhooks registered on a stack
+func (c *Client) ListStackWebhooks(ctx context.Context, orgName, projectName, stackName string) ([]Webhook, error) {
+	return c.listWebhooks(ctx, fmt.Sprintf("stacks/%s/%s/%s/hooks", orgName, projectName, stackName))
+}
+
+// DeleteOrgWebhook deletes a webhook registered on the organization
+func (c *Client) DeleteOrgWebhook(ctx context.Context, orgName, hookName string) error {
+	return c.deleteWebhook(ctx, fmt.Sprintf("orgs/%s/hooks/%s", orgName, hookName))
+}
+
+// DeleteStackWebhook deletes a webhook registered on a stack
+func (c *Client) DeleteStackWebhook(ctx context.Context, orgName, projectName, stackName, hookName string) error {
+	return c.deleteWebhook(ctx, fmt.Sprintf("stacks/%s/%s/%s/hooks/%s", orgName, projectName, stackName, hookName))
+}
+
+func (c *Client) listWebhooks(ctx context.Context, path string) ([]Webhook, error) {
+	reqURL, err := c.buildURL(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "GET", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var webhooks []Webhook
+	resp, err := c.send(req, uhttp.WithJSONResponse(&webhooks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return webhooks, nil
+}
+
+func (c *Client) deleteWebhook(ctx context.Context, path string) error {
+	reqURL, err := c.buildURL(path, nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.baseHttpClient.NewRequest(ctx, "DELETE", reqURL, c.requestOptions(nil)...)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// accessTokenBuilder syncs organization and team access tokens, and the personal tokens of the user
+// owning the connector's token. Pulumi only lets their owner see personal tokens, so those of other
+// users are not synced.
+type accessTokenBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+	members      *memberIndex
+	staleness    *tokenStaleness
+}
+
+var _ connectorbuilder.ResourceSyncer = &accessTokenBuilder{}
+var _ connectorbuilder.ResourceDeleter = &accessTokenBuilder{}
+
+// personalTokenPrefix starts the resource IDs of personal tokens. Team names cannot contain a colon.
+const personalTokenPrefix = "personal:"
+
+// accessTokenID returns the resource ID of a token: its ID for organization tokens, and the team
+// name and token ID for team tokens
+func accessTokenID(teamName, tokenID string) string {
+	if teamName == "" {
+		return tokenID
+	}
+	return teamName + "/" + tokenID
+}
+
+func accessTokenResource(token client.AccessToken, resourceID string, createdBy string, staleness *tokenStaleness, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	var traitOpts []batonResource.SecretTraitOption
+	if created, ok := unixTimestamp(token.Created); ok {
+		traitOpts = append(traitOpts, batonResource.WithSecretCreatedAt(created))
+	}
+	if lastUsed, ok := unixTimestamp(token.LastUsed); ok {
+		traitOpts = append(traitOpts, batonResource.WithSecretLastUsedAt(lastUsed))
+	}
+	if expires, ok := unixTimestamp(token.Expires); ok {
+		traitOpts = append(traitOpts, batonResource.WithSecretExpiresAt(expires))
+	}
+	if createdBy != "" {
+		traitOpts = append(traitOpts, batonResource.WithSecretCreatedByID(&v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     createdBy,
+		}))
+	}
+	// Tokens act as their team or user, or as the organization for organization tokens
+	traitOpts = append(traitOpts, batonResource.WithSecretIdentityID(parentResourceId))
+
+	name := token.Name
+	if name == "" {
+		name = token.Description
+	}
+	if name == "" {
+		name = token.ID
+	}
+
+	stalenessTraitOpts, opts, err := staleness.options(token.Created, token.LastUsed)
+	if err != nil {
+		return nil, err
+	}
+	traitOpts = append(traitOpts, stalenessTraitOpts...)
+	opts = append(opts, batonResource.WithParentResourceID(parentResourceId))
+
+	return batonResource.NewSecretResource(
+		name,
+		accessTokenResourceType,
+		resourceID,
+		traitOpts,
+		opts...,
+	)
+}
+
+func (o *accessTokenBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return accessTokenResourceType
+}
+
+// List returns the organization tokens when listed under the organization, a team's tokens when
+// listed under the team, and the personal tokens of the connector token's owner when listed under
+// that user.
+func (o *accessTokenBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	if parentResourceID.ResourceType == userResourceType.Id {
+		return o.listPersonalTokens(ctx, parentResourceID)
+	}
+
+	var tokens []client.AccessToken
+	var teamName string
+	var err error
+	switch parentResourceID.ResourceType {
+	case orgResourceType.Id:
+		tokens, err = o.client.ListOrgTokens(ctx, o.orgName)
+	case teamResourceType.Id:
+		teamName = parentResourceID.Resource
+		tokens, err = o.client.ListTeamTokens(ctx, o.orgName, teamName)
+	default:
+		return nil, "", nil, nil
+	}
+	switch {
+	case client.IsPermissionDenied(err):
+		// A token without access to some tokens still syncs the rest
+		ctxzap.Extract(ctx).Warn("token cannot list access tokens, skipping them",
+			zap.String("parent_type", parentResourceID.ResourceType),
+			zap.String("parent", parentResourceID.Resource),
+			zap.Error(err),
+		)
+		return nil, "", nil, nil
+	case err != nil:
+		return nil, "", nil, err
+	}
+
+	resources := make([]*v2.Resource, 0, len(tokens))
+	for _, token := range tokens {
+		createdBy, err := o.members.loginPrincipalID(ctx, token.CreatedBy)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resource, err := accessTokenResource(token, accessTokenID(teamName, token.ID), createdBy, o.staleness, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// listPersonalTokens returns the personal tokens of a user, which are only visible when the
+// connector authenticates with a personal token of that same user
+func (o *accessTokenBuilder) listPersonalTokens(ctx context.Context, userID *v2.ResourceId) ([]*v2.Resource, string, annotations.Annotations, error) {
+	owner, err := personalTokenOwner(ctx, o.client)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if owner == "" {
+		return nil, "", nil, nil
+	}
+	login, err := o.members.login(ctx, userID.Resource)
+	if err != nil || login != owner {
+		return nil, "", nil, nil
+	}
+
+	tokens, err := o.client.ListPersonalTokens(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	resources := make([]*v2.Resource, 0, len(tokens))
+	for _, token := range tokens {
+		resource, err := accessTokenResource(token, personalTokenPrefix+token.ID, userID.Resource, o.staleness, userID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// personalTokenOwner returns the login of the user whose personal token the connector uses, or an
+// empty login when it uses an organization or team token
+func personalTokenOwner(ctx context.Context, c *client.Client) (string, error) {
+	identity, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up access token identity: %w", err)
+	}
+	if identity.TokenType() != client.TokenTypePersonal {
+		return "", nil
+	}
+	return identity.GithubLogin, nil
+}
+
+// Entitlements returns an empty list since tokens don't have entitlements
+func (o *accessTokenBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns an empty list since tokens don't have entitlements
+func (o *accessTokenBuilder) Grants(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Delete revokes an organization, team or personal access token. Personal tokens can only be
+// revoked while the connector uses a personal token of the same user.
+func (o *accessTokenBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId == nil || resourceId.Resource == "" {
+		return nil, fmt.Errorf("access token resource id is empty")
+	}
+	if resourceId.ResourceType != accessTokenResourceType.Id {
+		return nil, fmt.Errorf("cannot delete non-access-token resource type: %s", resourceId.ResourceType)
+	}
+
+	if tokenID, ok := strings.CutPrefix(resourceId.Resource, personalTokenPrefix); ok {
+		err := o.client.DeletePersonalToken(ctx, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete personal token: %w", err)
+		}
+		return nil, nil
+	}
+
+	teamName, tokenID, isTeamToken := strings.Cut(resourceId.Resource, "/")
+	if !isTeamToken {
+		err := o.client.DeleteOrgToken(ctx, o.orgName, resourceId.Resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete organization token: %w", err)
+		}
+		return nil, nil
+	}
+
+	err := o.client.DeleteTeamToken(ctx, o.orgName, teamName, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete team token: %w", err)
+	}
+	return nil, nil
+}
+
+func newAccessTokenBuilder(client *client.Client, orgName string, members *memberIndex, staleness *tokenStaleness) *accessTokenBuilder {
+	return &accessTokenBuilder{
+		resourceType: accessTokenResourceType,
+		client:       client,
+		orgName:      orgName,
+		members:      members,
+		staleness:    staleness,
+	}
+}
@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+)
+
+func TestAccessTokenListMapsCreator(t *testing.T) {
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/orgs/acme/tokens": jsonResponse(`{"tokens": [
+			{"id": "t1", "name": "ci", "created": 1700000000, "createdBy": "alice"},
+			{"id": "t2", "name": "old", "created": 1700000000, "createdBy": "departed"},
+			{"id": "t3", "name": "bot", "created": 1700000000}
+		]}`),
+		membersRoute: jsonResponse(`{"members": [{"role": "admin", "user": {"githubLogin": "alice", "email": "alice@example.com"}}]}`),
+	})
+	c := api.client(t)
+	builder := newAccessTokenBuilder(c, "acme", newMemberIndex(c, "acme", PrincipalKeyEmail), nil)
+
+	resources, _, _, err := builder.List(context.Background(), &v2.ResourceId{ResourceType: orgResourceType.Id, Resource: "acme"}, nil)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	want := map[string]string{"t1": "alice@example.com", "t2": "departed", "t3": ""}
+	if len(resources) != len(want) {
+		t.Fatalf("List returned %d tokens, want %d", len(resources), len(want))
+	}
+	for _, resource := range resources {
+		trait := &v2.SecretTrait{}
+		annos := annotations.Annotations(resource.Annotations)
+		if ok, err := annos.Pick(trait); err != nil || !ok {
+			t.Fatalf("token %s has no secret trait: %v", resource.Id.Resource, err)
+		}
+		if got := trait.GetCreatedById().GetResource(); got != want[resource.Id.Resource] {
+			t.Errorf("token %s created by %q, want %q", resource.Id.Resource, got, want[resource.Id.Resource])
+		}
+	}
+}
+
+func TestAccessTokenListSkipsForbiddenTokens(t *testing.T) {
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/orgs/acme/teams/platform/tokens": statusResponse(http.StatusForbidden),
+		"GET /api/orgs/acme/teams/broken/tokens":   statusResponse(http.StatusInternalServerError),
+	})
+	c := api.client(t)
+	builder := newAccessTokenBuilder(c, "acme", newMemberIndex(c, "acme", PrincipalKeyLogin), nil)
+	ctx := context.Background()
+
+	resources, _, _, err := builder.List(ctx, &v2.ResourceId{ResourceType: teamResourceType.Id, Resource: "platform"}, nil)
+	if err != nil {
+		t.Fatalf("List failed for a forbidden team: %v", err)
+	}
+	if len(resources) != 0 {
+		t.Errorf("List returned %d tokens for a forbidden team, want none", len(resources))
+	}
+
+	if _, _, _, err := builder.List(ctx, &v2.ResourceId{ResourceType: teamResourceType.Id, Resource: "broken"}, nil); err == nil {
+		t.Error("List succeeded although listing the tokens failed")
+	}
+}
+
+func TestAccessTokenListPersonalTokensOfOwner(t *testing.T) {
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/user":        jsonResponse(`{"githubLogin": "alice"}`),
+		"GET /api/user/tokens": jsonResponse(`{"tokens": [{"id": "p1", "name": "laptop", "created": 1700000000}]}`),
+		"DELETE /api/user/tokens/p1": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+		membersRoute: jsonResponse(`{"members": [
+			{"role": "admin", "user": {"githubLogin": "alice", "email": "alice@example.com"}},
+			{"role": "member", "user": {"githubLogin": "bob", "email": "bob@example.com"}}
+		]}`),
+	})
+	c := api.client(t)
+	builder := newAccessTokenBuilder(c, "acme", newMemberIndex(c, "acme", PrincipalKeyEmail), nil)
+	ctx := context.Background()
+
+	resources, _, _, err := builder.List(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "bob@example.com"}, nil)
+	if err != nil {
+		t.Fatalf("List failed for another user: %v", err)
+	}
+	if len(resources) != 0 {
+		t.Errorf("List returned %d personal tokens of another user, want none", len(resources))
+	}
+
+	resources, _, _, err = builder.List(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "alice@example.com"}, nil)
+	if err != nil {
+		t.Fatalf("List failed for the token owner: %v", err)
+	}
+	if len(resources) != 1 || resources[0].Id.Resource != "personal:p1" {
+		t.Fatalf("List returned %v, want the personal token p1", resources)
+	}
+
+	if _, err := builder.Delete(ctx, resources[0].Id); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if api.called("DELETE /api/user/tokens/p1") != 1 {
+		t.Error("Delete did not revoke the personal token")
+	}
+}
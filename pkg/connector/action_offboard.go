@@ -0,0 +1,196 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const actionOffboardUser = "offboard_user"
+
+var offboardUserSchema = &v2.BatonActionSchema{
+	Name:        actionOffboardUser,
+	DisplayName: "Offboard user",
+	Description: "Remove a user from all teams and stacks and from the organization, optionally handing the stacks they administer to a team",
+	Arguments: []*configv1.Field{
+		stringArgField("user_id", "User", "Resource ID of the user to offboard", true),
+		stringArgField("transfer_to_team", "Transfer to team", "Team given admin permission on stacks the user administers", false),
+	},
+	ReturnTypes: []*configv1.Field{
+		stringArgField("login", "Login", "Pulumi login of the offboarded user", false),
+	},
+}
+
+// offboardUser removes a user from every team, drops their direct stack permissions and removes
+// them from the organization. Stacks on which the user is a direct admin count as owned by them and
+// are handed to the transfer team first so they are not left without an administrator. Each step
+// is attempted even if earlier ones fail, and failures are listed in the report.
+func (m *actionManager) offboardUser(ctx context.Context, args *structpb.Struct) (*actionResult, error) {
+	userID, err := stringArg(args, "user_id", true)
+	if err != nil {
+		return nil, err
+	}
+	transferTeam, err := stringArg(args, "transfer_to_team", false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything is read past the caches, so that memberships and stacks created since the last
+	// sync are not missed
+	fresh := client.Uncached(ctx)
+
+	member, err := m.members.current(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, fmt.Errorf("%s is not a member of organization %s", userID, m.orgName)
+	}
+	login := member.User.GithubLogin
+	if transferTeam != "" {
+		if _, err := m.client.GetTeam(fresh, m.orgName, transferTeam); err != nil {
+			return nil, fmt.Errorf("transfer team %s: %w", transferTeam, err)
+		}
+	}
+
+	l := ctxzap.Extract(ctx).With(zap.String("login", login))
+	var failures []interface{}
+	fail := func(step string, err error) {
+		l.Warn("offboarding step failed", zap.String("step", step), zap.Error(err))
+		failures = append(failures, fmt.Sprintf("%s: %s", step, err))
+	}
+
+	teamsRemoved := m.offboardTeams(fresh, login, fail)
+	stacksTransferred, stackPermissionsRemoved := m.offboardStacks(fresh, login, transferTeam, fail)
+
+	orgMembershipRemoved := false
+	scimManaged := m.scim.memberIsManaged(fresh, *member)
+	err = m.scim.checkRevoke(fresh, scimManaged, fmt.Sprintf("membership of %s in organization %s", login, m.orgName))
+	if err == nil {
+		err = m.client.RemoveUser(fresh, m.orgName, login)
+	}
+	if err != nil {
+		fail("remove from organization", err)
+	} else {
+		orgMembershipRemoved = true
+	}
+
+	report, err := structpb.NewStruct(map[string]interface{}{
+		"login":                     login,
+		"teams_removed":             teamsRemoved,
+		"stacks_transferred":        stacksTransferred,
+		"stack_permissions_removed": stackPermissionsRemoved,
+		"transfer_to_team":          transferTeam,
+		"org_membership_removed":    orgMembershipRemoved,
+		"failures":                  failures,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build offboarding report: %w", err)
+	}
+
+	return &actionResult{status: actionStatus(failures), response: report}, nil
+}
+
+// offboardTeams removes the user from every team they belong to, except SCIM-managed teams
+// without the override, which are reported as failures
+func (m *actionManager) offboardTeams(ctx context.Context, login string, fail func(string, error)) []interface{} {
+	teams, err := m.client.ListTeams(ctx, m.orgName)
+	if err != nil {
+		fail("list teams", err)
+		return nil
+	}
+
+	var removed []interface{}
+	for _, summary := range teams {
+		team, err := m.client.GetTeam(ctx, m.orgName, summary.Name)
+		if err != nil {
+			fail(fmt.Sprintf("get team %s", summary.Name), err)
+			continue
+		}
+		if !teamHasMember(team, login) {
+			continue
+		}
+
+		// SCIM would add the user back to the team, so leave it to the identity provider
+		scimManaged := m.scim.teamIsManaged(ctx, *team)
+		if err := m.scim.checkRevoke(ctx, scimManaged, fmt.Sprintf("membership of %s in team %s", login, team.Name)); err != nil {
+			fail(fmt.Sprintf("remove from team %s", team.Name), err)
+			continue
+		}
+
+		if err := m.client.UpdateTeamMembership(ctx, m.orgName, team.Name, login, "remove"); err != nil {
+			fail(fmt.Sprintf("remove from team %s", team.Name), err)
+			continue
+		}
+		removed = append(removed, team.Name)
+	}
+
+	return removed
+}
+
+func teamHasMember(team *client.Team, login string) bool {
+	for _, member := range team.Members {
+		if member.GithubLogin == login {
+			return true
+		}
+	}
+	return false
+}
+
+// offboardStacks removes the user's direct stack permissions, first giving the transfer team admin
+// permission on stacks the user administers
+func (m *actionManager) offboardStacks(ctx context.Context, login, transferTeam string, fail func(string, error)) ([]interface{}, []interface{}) {
+	var transferred, removed []interface{}
+
+	var token string
+	for {
+		resp, err := m.client.ListStacks(ctx, m.orgName, token)
+		if err != nil {
+			fail("list stacks", err)
+			return transferred, removed
+		}
+
+		for _, stack := range resp.Stacks {
+			id := stackID(stack.ProjectName, stack.StackName)
+			collaborators, err := m.client.ListStackCollaborators(ctx, m.orgName, stack.ProjectName, stack.StackName)
+			if err != nil {
+				fail(fmt.Sprintf("list collaborators of stack %s", id), err)
+				continue
+			}
+
+			for _, collaborator := range collaborators {
+				if collaborator.User.GithubLogin != login {
+					continue
+				}
+
+				if collaborator.Permission == client.StackPermissionAdmin && transferTeam != "" {
+					err := m.client.SetTeamStackPermission(ctx, m.orgName, transferTeam, stack.ProjectName, stack.StackName, client.StackPermissionAdmin)
+					if err != nil {
+						// Keep the user's permission rather than leave the stack without an administrator
+						fail(fmt.Sprintf("transfer stack %s", id), err)
+						break
+					}
+					transferred = append(transferred, id)
+				}
+
+				if err := m.client.RemoveStackCollaborator(ctx, m.orgName, stack.ProjectName, stack.StackName, login); err != nil {
+					fail(fmt.Sprintf("remove permission on stack %s", id), err)
+					break
+				}
+				removed = append(removed, id)
+				break
+			}
+		}
+
+		if resp.ContinuationToken == "" {
+			return transferred, removed
+		}
+		token = resp.ContinuationToken
+	}
+}
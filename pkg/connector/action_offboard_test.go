@@ -0,0 +1,57 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestOffboardUserReadsCurrentState(t *testing.T) {
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		membersRoute:                          jsonResponse(`{"members": [{"role": "member", "user": {"githubLogin": "bob"}}]}`),
+		"GET /api/orgs/acme/teams":            jsonResponse(`{"teams": [{"name": "platform"}]}`),
+		"GET /api/orgs/acme/teams/platform":   jsonResponse(`{"name": "platform", "kind": "pulumi", "members": [{"githubLogin": "bob"}]}`),
+		"GET /api/user/stacks":                jsonResponse(`{"stacks": []}`),
+		"PATCH /api/orgs/acme/teams/platform": statusResponse(http.StatusNoContent),
+		"DELETE /api/orgs/acme/members/alice": statusResponse(http.StatusNoContent),
+	})
+	m := api.actionManager(t)
+	ctx := context.Background()
+
+	// A sync reads the members and teams before alice joins
+	if _, err := m.members.find(ctx, "bob", false); err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if _, err := m.client.GetTeam(ctx, "acme", "platform"); err != nil {
+		t.Fatalf("GetTeam failed: %v", err)
+	}
+
+	api.set(membersRoute, jsonResponse(`{"members": [
+		{"role": "member", "user": {"githubLogin": "bob"}},
+		{"role": "member", "user": {"githubLogin": "alice"}}
+	]}`))
+	api.set("GET /api/orgs/acme/teams/platform", jsonResponse(`{"name": "platform", "kind": "pulumi", "members": [{"githubLogin": "bob"}, {"githubLogin": "alice"}]}`))
+
+	args, err := structpb.NewStruct(map[string]interface{}{"user_id": "alice"})
+	if err != nil {
+		t.Fatalf("failed to build arguments: %v", err)
+	}
+	result, err := m.offboardUser(ctx, args)
+	if err != nil {
+		t.Fatalf("offboardUser failed: %v", err)
+	}
+
+	if result.status != v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE {
+		t.Errorf("status = %v, want complete: %v", result.status, result.response.GetFields()["failures"])
+	}
+	teams := result.response.GetFields()["teams_removed"].GetListValue().GetValues()
+	if len(teams) != 1 || teams[0].GetStringValue() != "platform" {
+		t.Errorf("teams removed = %v, want platform, which alice joined after the sync", teams)
+	}
+	if n := api.called("DELETE /api/orgs/acme/members/alice"); n != 1 {
+		t.Errorf("alice removed from the organization %d times, want 1", n)
+	}
+}
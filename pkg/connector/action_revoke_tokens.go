@@ -0,0 +1,204 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	actionRevokeTokens = "revoke_tokens"
+
+	tokenKindOrg  = "organization"
+	tokenKindTeam = "team"
+)
+
+var revokeTokensSchema = &v2.BatonActionSchema{
+	Name:        actionRevokeTokens,
+	DisplayName: "Revoke tokens",
+	Description: "Delete every access token of a team, or every team token created by a user",
+	Arguments: []*configv1.Field{
+		stringArgField("resource_type", "Resource type", "Type of the resource owning the tokens, user or team", true),
+		stringArgField("resource_id", "Resource", "Resource ID of the user or team", true),
+		boolArgField("include_org_tokens", "Include organization tokens", "Also delete the organization tokens the user created, which other automation may depend on"),
+	},
+	ReturnTypes: []*configv1.Field{
+		stringArgField("resource_type", "Resource type", "Type of the resource owning the tokens", false),
+		stringArgField("resource_id", "Resource", "Resource ID of the user or team", false),
+		{
+			Name:        "revoked",
+			DisplayName: "Revoked tokens",
+			Description: "IDs of the deleted tokens",
+			Field:       &configv1.Field_StringSliceField{StringSliceField: &configv1.StringSliceField{}},
+		},
+		{
+			Name:        "revoked_tokens",
+			DisplayName: "Revoked token details",
+			Description: "Description, kind (organization or team) and team of each deleted token, keyed by token ID",
+			Field:       &configv1.Field_StringMapField{StringMapField: &configv1.StringMapField{}},
+		},
+		{
+			Name:        "failures",
+			DisplayName: "Failures",
+			Description: "Tokens that could not be deleted, teams whose tokens could not be listed, and personal tokens, which the action cannot reach",
+			Field:       &configv1.Field_StringSliceField{StringSliceField: &configv1.StringSliceField{}},
+		},
+	},
+}
+
+// ownedToken is an access token along with where it lives
+type ownedToken struct {
+	token client.AccessToken
+	kind  string
+	team  string
+}
+
+func (t ownedToken) report() map[string]interface{} {
+	rv := map[string]interface{}{
+		"description": t.token.Description,
+		"kind":        t.kind,
+	}
+	if t.team != "" {
+		rv["team"] = t.team
+	}
+	return rv
+}
+
+// String names the token in failure messages
+func (t ownedToken) String() string {
+	if t.team != "" {
+		return fmt.Sprintf("%s token %s (%s) of team %s", t.kind, t.token.ID, t.token.Description, t.team)
+	}
+	return fmt.Sprintf("%s token %s (%s)", t.kind, t.token.ID, t.token.Description)
+}
+
+// revokeTokens deletes the access tokens of a team, or the team tokens created by a user along
+// with their organization tokens when include_org_tokens is set. Organization tokens outlive
+// their creator and usually back shared automation, so they are only deleted on request. Pulumi
+// does not let anyone but their owner list or delete personal tokens, so a user's personal tokens
+// are always reported as a failure; removing the user from the organization cuts them off. Every
+// token is attempted and failures, including teams whose tokens could not be listed, are
+// reported individually.
+func (m *actionManager) revokeTokens(ctx context.Context, args *structpb.Struct) (*actionResult, error) {
+	resourceType, err := stringArg(args, "resource_type", true)
+	if err != nil {
+		return nil, err
+	}
+	resourceID, err := stringArg(args, "resource_id", true)
+	if err != nil {
+		return nil, err
+	}
+	includeOrgTokens, err := boolArg(args, "include_org_tokens")
+	if err != nil {
+		return nil, err
+	}
+
+	l := ctxzap.Extract(ctx)
+	failures := []interface{}{}
+
+	var tokens []ownedToken
+	switch resourceType {
+	case teamResourceType.Id:
+		teamTokens, err := m.client.ListTeamTokens(ctx, m.orgName, resourceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range teamTokens {
+			tokens = append(tokens, ownedToken{token: token, kind: tokenKindTeam, team: resourceID})
+		}
+	case userResourceType.Id:
+		login, err := m.members.login(ctx, resourceID)
+		if err != nil {
+			return nil, err
+		}
+		tokens, err = m.tokensCreatedBy(ctx, login, includeOrgTokens, func(team string, err error) {
+			l.Warn("failed to list team access tokens", zap.String("team", team), zap.Error(err))
+			failures = append(failures, fmt.Sprintf("team %s: failed to list tokens: %s", team, err))
+		})
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, fmt.Sprintf(
+			"personal tokens of %s: not revoked, Pulumi only lets their owner list and delete them; remove %s from the organization to cut them off",
+			login, login,
+		))
+	default:
+		return nil, fmt.Errorf("cannot revoke tokens of resource type: %s", resourceType)
+	}
+
+	revoked := []interface{}{}
+	revokedTokens := map[string]interface{}{}
+	for _, t := range tokens {
+		var err error
+		switch t.kind {
+		case tokenKindOrg:
+			err = m.client.DeleteOrgToken(ctx, m.orgName, t.token.ID)
+		case tokenKindTeam:
+			err = m.client.DeleteTeamToken(ctx, m.orgName, t.team, t.token.ID)
+		}
+
+		if err != nil {
+			l.Warn("failed to revoke access token", zap.String("token_id", t.token.ID), zap.Error(err))
+			failures = append(failures, fmt.Sprintf("%s: %s", t, err))
+			continue
+		}
+		revoked = append(revoked, t.token.ID)
+		revokedTokens[t.token.ID] = t.report()
+	}
+
+	response, err := structpb.NewStruct(map[string]interface{}{
+		"resource_type":  resourceType,
+		"resource_id":    resourceID,
+		"revoked":        revoked,
+		"revoked_tokens": revokedTokens,
+		"failures":       failures,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build revocation report: %w", err)
+	}
+
+	return &actionResult{status: actionStatus(failures), response: response}, nil
+}
+
+// tokensCreatedBy returns the team tokens created by a user, and their organization tokens when
+// includeOrgTokens is set. Teams whose tokens cannot be listed are passed to fail and skipped.
+func (m *actionManager) tokensCreatedBy(ctx context.Context, login string, includeOrgTokens bool, fail func(team string, err error)) ([]ownedToken, error) {
+	var rv []ownedToken
+
+	if includeOrgTokens {
+		orgTokens, err := m.client.ListOrgTokens(ctx, m.orgName)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range orgTokens {
+			if token.CreatedBy == login {
+				rv = append(rv, ownedToken{token: token, kind: tokenKindOrg})
+			}
+		}
+	}
+
+	teams, err := m.client.ListTeams(ctx, m.orgName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	for _, team := range teams {
+		teamTokens, err := m.client.ListTeamTokens(ctx, m.orgName, team.Name)
+		if err != nil {
+			fail(team.Name, err)
+			continue
+		}
+		for _, token := range teamTokens {
+			if token.CreatedBy == login {
+				rv = append(rv, ownedToken{token: token, kind: tokenKindTeam, team: team.Name})
+			}
+		}
+	}
+
+	return rv, nil
+}
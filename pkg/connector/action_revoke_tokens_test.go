@@ -0,0 +1,151 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func revokeTokensAPI() *fakeAPI {
+	return newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/orgs/acme/tokens": jsonResponse(`{"tokens":[
+			{"id":"org-1","description":"ci","createdBy":"alice"},
+			{"id":"org-2","description":"billing","createdBy":"bob"}
+		]}`),
+		"GET /api/orgs/acme/teams": jsonResponse(`{"teams":[{"name":"platform"},{"name":"restricted"}]}`),
+		"GET /api/orgs/acme/teams/platform/tokens": jsonResponse(`{"tokens":[
+			{"id":"team-1","description":"deploy","createdBy":"alice"},
+			{"id":"team-2","description":"preview","createdBy":"bob"}
+		]}`),
+		"GET /api/orgs/acme/teams/restricted/tokens":         statusResponse(http.StatusForbidden),
+		"DELETE /api/orgs/acme/tokens/org-1":                 statusResponse(http.StatusNoContent),
+		"DELETE /api/orgs/acme/teams/platform/tokens/team-1": statusResponse(http.StatusNoContent),
+	})
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	tests := []struct {
+		name             string
+		includeOrgTokens bool
+		wantRevoked      []string
+	}{
+		{name: "team tokens only", wantRevoked: []string{"team-1"}},
+		{name: "with organization tokens", includeOrgTokens: true, wantRevoked: []string{"org-1", "team-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := revokeTokensAPI()
+			m := api.actionManager(t)
+
+			args, err := structpb.NewStruct(map[string]interface{}{
+				"resource_type":      userResourceType.Id,
+				"resource_id":        "alice",
+				"include_org_tokens": tt.includeOrgTokens,
+			})
+			if err != nil {
+				t.Fatalf("failed to build arguments: %v", err)
+			}
+
+			result, err := m.revokeTokens(context.Background(), args)
+			if err != nil {
+				t.Fatalf("revokeTokens failed: %v", err)
+			}
+
+			var revoked []string
+			for _, entry := range result.response.GetFields()["revoked"].GetListValue().GetValues() {
+				revoked = append(revoked, entry.GetStringValue())
+			}
+			if len(revoked) != len(tt.wantRevoked) {
+				t.Fatalf("revoked %v, want %v", revoked, tt.wantRevoked)
+			}
+			for i := range revoked {
+				if revoked[i] != tt.wantRevoked[i] {
+					t.Errorf("revoked %v, want %v", revoked, tt.wantRevoked)
+				}
+			}
+
+			details := result.response.GetFields()["revoked_tokens"].GetStructValue().GetFields()
+			if details["team-1"].GetStructValue().GetFields()["description"].GetStringValue() != "deploy" {
+				t.Errorf("revoked token details = %v, want team-1 described as deploy", details)
+			}
+
+			// The team whose tokens could not be listed and the personal tokens, which cannot be
+			// reached, are reported rather than failing the action
+			failures := result.response.GetFields()["failures"].GetListValue().GetValues()
+			if len(failures) != 2 ||
+				!strings.HasPrefix(failures[0].GetStringValue(), "team restricted:") ||
+				!strings.HasPrefix(failures[1].GetStringValue(), "personal tokens of alice:") {
+				t.Errorf("failures = %v, want the restricted team and the personal tokens", failures)
+			}
+			if result.status != v2.BatonActionStatus_BATON_ACTION_STATUS_FAILED {
+				t.Errorf("status = %v, want failed", result.status)
+			}
+
+			assertResultMatchesSchema(t, revokeTokensSchema, result.response)
+
+			if !tt.includeOrgTokens && api.called("GET /api/orgs/acme/tokens") != 0 {
+				t.Error("organization tokens were listed without include_org_tokens")
+			}
+			if n := api.called("DELETE /api/orgs/acme/tokens/org-2") + api.called("DELETE /api/orgs/acme/teams/platform/tokens/team-2"); n != 0 {
+				t.Errorf("deleted %d tokens created by someone else", n)
+			}
+		})
+	}
+}
+
+func TestRevokeTokensRejectsNonBooleanFlag(t *testing.T) {
+	m := newFakeAPI(nil).actionManager(t)
+
+	args, err := structpb.NewStruct(map[string]interface{}{
+		"resource_type":      userResourceType.Id,
+		"resource_id":        "alice",
+		"include_org_tokens": "yes",
+	})
+	if err != nil {
+		t.Fatalf("failed to build arguments: %v", err)
+	}
+
+	if _, err := m.revokeTokens(context.Background(), args); err == nil {
+		t.Error("revokeTokens accepted a string include_org_tokens")
+	}
+}
+
+// assertResultMatchesSchema checks that every field of an action's result is declared among the
+// action's return types with a matching kind
+func assertResultMatchesSchema(t *testing.T, schema *v2.BatonActionSchema, result *structpb.Struct) {
+	t.Helper()
+
+	declared := make(map[string]*configv1.Field)
+	for _, field := range schema.GetReturnTypes() {
+		declared[field.GetName()] = field
+	}
+
+	for name, value := range result.GetFields() {
+		field, ok := declared[name]
+		if !ok {
+			t.Errorf("result field %s is not declared", name)
+			continue
+		}
+
+		var matches bool
+		switch value.GetKind().(type) {
+		case *structpb.Value_StringValue:
+			matches = field.GetStringField() != nil
+		case *structpb.Value_BoolValue:
+			matches = field.GetBoolField() != nil
+		case *structpb.Value_ListValue:
+			matches = field.GetStringSliceField() != nil
+		case *structpb.Value_StructValue:
+			matches = field.GetStringMapField() != nil
+		}
+		if !matches {
+			t.Errorf("result field %s = %v does not match its declared type %T", name, value, field.GetField())
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	actionLockStack   = "lock_stack"
+	actionUnlockStack = "unlock_stack"
+
+	// stackLockTag marks locked stacks so the lock shows up in the Pulumi console and in stack profiles
+	stackLockTag = "baton-locked"
+)
+
+var lockStackSchema = &v2.BatonActionSchema{
+	Name:        actionLockStack,
+	DisplayName: "Lock stack",
+	Description: "Cancel the update in progress on a stack, pause its deployments and tag it as locked",
+	Arguments: []*configv1.Field{
+		stringArgField("stack_id", "Stack", "Resource ID of the stack, as project/stack", true),
+		stringArgField("reason", "Reason", "Why the stack is locked, recorded in the lock tag", false),
+	},
+	ReturnTypes: []*configv1.Field{
+		stringArgField("cancelled_update", "Cancelled update", "ID of the update that was cancelled, if any", false),
+	},
+}
+
+var unlockStackSchema = &v2.BatonActionSchema{
+	Name:        actionUnlockStack,
+	DisplayName: "Unlock stack",
+	Description: "Resume the deployments of a locked stack and remove its lock tag",
+	Arguments: []*configv1.Field{
+		stringArgField("stack_id", "Stack", "Resource ID of the stack, as project/stack", true),
+	},
+}
+
+// lockStack freezes a stack. Pulumi Deployments are paused, which stops queued and new deployments,
+// but updates run from the CLI are not blocked by Pulumi; the lock tag tells engineers to hold off.
+// Cancelling an update is asynchronous, so the action keeps running until the update has stopped.
+func (m *actionManager) lockStack(ctx context.Context, args *structpb.Struct) (*actionResult, error) {
+	id, err := stringArg(args, "stack_id", true)
+	if err != nil {
+		return nil, err
+	}
+	reason, err := stringArg(args, "reason", false)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		reason = "locked"
+	}
+
+	projectName, stackName, err := parseStackID(id)
+	if err != nil {
+		return nil, err
+	}
+	stack, err := m.currentStack(ctx, projectName, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.client.PauseStackDeployments(ctx, m.orgName, projectName, stackName); err != nil {
+		return nil, err
+	}
+	if err := m.client.SetStackTag(ctx, m.orgName, projectName, stackName, stackLockTag, reason); err != nil {
+		return nil, err
+	}
+
+	cancelledUpdate := stack.ActiveUpdate
+	if cancelledUpdate != "" {
+		if err := m.client.CancelStackUpdate(ctx, m.orgName, projectName, stackName); err != nil {
+			return nil, err
+		}
+	}
+
+	report := func(updateRunning bool) (*structpb.Struct, error) {
+		return structpb.NewStruct(map[string]interface{}{
+			"stack_id":           id,
+			"locked":             true,
+			"reason":             reason,
+			"deployments_paused": true,
+			"cancelled_update":   cancelledUpdate,
+			"update_running":     updateRunning,
+		})
+	}
+
+	if cancelledUpdate == "" {
+		response, err := report(false)
+		if err != nil {
+			return nil, err
+		}
+		return &actionResult{status: v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE, response: response}, nil
+	}
+
+	response, err := report(true)
+	if err != nil {
+		return nil, err
+	}
+	return &actionResult{
+		status:   v2.BatonActionStatus_BATON_ACTION_STATUS_RUNNING,
+		response: response,
+		refresh: func(ctx context.Context) (v2.BatonActionStatus, *structpb.Struct, error) {
+			running, err := m.updateRunning(ctx, projectName, stackName, cancelledUpdate)
+			if err != nil {
+				return v2.BatonActionStatus_BATON_ACTION_STATUS_UNKNOWN, nil, err
+			}
+
+			response, err := report(running)
+			if err != nil {
+				return v2.BatonActionStatus_BATON_ACTION_STATUS_UNKNOWN, nil, err
+			}
+			if running {
+				return v2.BatonActionStatus_BATON_ACTION_STATUS_RUNNING, response, nil
+			}
+			return v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE, response, nil
+		},
+	}, nil
+}
+
+// currentStack gets a stack bypassing cached responses, which may predate the update in progress
+func (m *actionManager) currentStack(ctx context.Context, projectName, stackName string) (*client.Stack, error) {
+	return m.client.GetStack(client.Uncached(ctx), m.orgName, projectName, stackName)
+}
+
+// updateRunning reports whether an update is still in progress on a stack
+func (m *actionManager) updateRunning(ctx context.Context, projectName, stackName, updateID string) (bool, error) {
+	stack, err := m.currentStack(ctx, projectName, stackName)
+	if err != nil {
+		return false, err
+	}
+	return stack.ActiveUpdate == updateID, nil
+}
+
+// unlockStack undoes lockStack. Cancelled updates are not restarted.
+func (m *actionManager) unlockStack(ctx context.Context, args *structpb.Struct) (*actionResult, error) {
+	id, err := stringArg(args, "stack_id", true)
+	if err != nil {
+		return nil, err
+	}
+	projectName, stackName, err := parseStackID(id)
+	if err != nil {
+		return nil, err
+	}
+	stack, err := m.currentStack(ctx, projectName, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.client.ResumeStackDeployments(ctx, m.orgName, projectName, stackName); err != nil {
+		return nil, err
+	}
+	if _, ok := stack.Tags[stackLockTag]; ok {
+		if err := m.client.DeleteStackTag(ctx, m.orgName, projectName, stackName, stackLockTag); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := structpb.NewStruct(map[string]interface{}{
+		"stack_id":           id,
+		"locked":             false,
+		"deployments_paused": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &actionResult{status: v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE, response: response}, nil
+}
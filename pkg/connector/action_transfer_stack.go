@@ -0,0 +1,199 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const actionTransferStack = "transfer_stack"
+
+var transferStackSchema = &v2.BatonActionSchema{
+	Name:        actionTransferStack,
+	DisplayName: "Transfer stack",
+	Description: "Move a stack to another organization or project and grant teams permissions on it there",
+	Arguments: []*configv1.Field{
+		stringArgField("stack_id", "Stack", "Resource ID of the stack, as project/stack", true),
+		stringArgField("destination_org", "Destination organization", "Organization to move the stack to, defaults to the current organization", false),
+		stringArgField("destination_project", "Destination project", "Project to move the stack to, defaults to its current project", false),
+		{
+			Name:        "team_permissions",
+			DisplayName: "Team permissions",
+			Description: "Permission (read, write or admin) to grant each team of the destination organization on the moved stack",
+			Field:       &configv1.Field_StringMapField{StringMapField: &configv1.StringMapField{}},
+		},
+	},
+	ReturnTypes: []*configv1.Field{
+		stringArgField("destination_stack_id", "Destination stack", "Resource ID of the stack after the move", false),
+	},
+}
+
+// projectNamePattern matches the project names Pulumi accepts
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+
+func validProjectName(name string) bool {
+	return projectNamePattern.MatchString(name)
+}
+
+// transferStack moves a stack to another organization with Pulumi's transfer endpoint and to another
+// project by renaming it, then grants teams permissions on it from the team_permissions mapping. The
+// destination organization and every mapped team are checked before anything is moved. Permission
+// failures after the move are reported per team.
+func (m *actionManager) transferStack(ctx context.Context, args *structpb.Struct) (*actionResult, error) {
+	id, err := stringArg(args, "stack_id", true)
+	if err != nil {
+		return nil, err
+	}
+	destOrg, err := stringArg(args, "destination_org", false)
+	if err != nil {
+		return nil, err
+	}
+	destProject, err := stringArg(args, "destination_project", false)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := stringMapArg(args, "team_permissions")
+	if err != nil {
+		return nil, err
+	}
+
+	projectName, stackName, err := parseStackID(id)
+	if err != nil {
+		return nil, err
+	}
+	if destOrg == "" {
+		destOrg = m.orgName
+	}
+	if destProject == "" {
+		destProject = projectName
+	}
+	if destOrg == m.orgName && destProject == projectName {
+		return nil, fmt.Errorf("stack %s is already in project %s of organization %s", id, projectName, m.orgName)
+	}
+
+	if !validProjectName(destProject) {
+		return nil, fmt.Errorf("invalid destination project %q: project names may only contain letters, digits, hyphens, underscores and periods", destProject)
+	}
+
+	// Everything is read past the caches, which may predate changes made outside the sync
+	fresh := client.Uncached(ctx)
+	if _, err := m.client.GetStack(fresh, m.orgName, projectName, stackName); err != nil {
+		return nil, err
+	}
+	if _, err := m.client.GetOrganization(fresh, destOrg); err != nil {
+		return nil, fmt.Errorf("destination organization %s: %w", destOrg, err)
+	}
+	_, err = m.client.GetStack(fresh, destOrg, destProject, stackName)
+	switch {
+	case err == nil:
+		return nil, fmt.Errorf("destination project %s of organization %s already has a stack named %s", destProject, destOrg, stackName)
+	case !client.IsNotFound(err):
+		return nil, fmt.Errorf("destination stack %s: %w", stackID(destProject, stackName), err)
+	}
+	permissions := make(map[string]int, len(mapping))
+	for team, slug := range mapping {
+		permission, ok := stackPermissionForSlug(slug)
+		if !ok {
+			return nil, fmt.Errorf("invalid permission %q for team %s: must be read, write or admin", slug, team)
+		}
+		if _, err := m.client.GetTeam(fresh, destOrg, team); err != nil {
+			return nil, fmt.Errorf("team %s in destination organization %s: %w", team, destOrg, err)
+		}
+		permissions[team] = permission
+	}
+
+	before, err := m.stackTeamPermissions(fresh, m.orgName, projectName, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if destOrg != m.orgName {
+		if err := m.client.TransferStack(ctx, m.orgName, projectName, stackName, destOrg); err != nil {
+			return nil, err
+		}
+	}
+	if destProject != projectName {
+		if err := m.client.RenameStack(ctx, destOrg, projectName, stackName, destProject, stackName); err != nil {
+			return nil, fmt.Errorf("stack was moved to organization %s but not to project %s: %w", destOrg, destProject, err)
+		}
+	}
+
+	l := ctxzap.Extract(ctx)
+	failures := []interface{}{}
+	teams := make([]string, 0, len(permissions))
+	for team := range permissions {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+	for _, team := range teams {
+		err := m.client.SetTeamStackPermission(ctx, destOrg, team, destProject, stackName, permissions[team])
+		if err != nil {
+			l.Warn("failed to grant team permission on transferred stack", zap.String("team", team), zap.Error(err))
+			failures = append(failures, fmt.Sprintf("team %s: %s", team, err))
+		}
+	}
+
+	// Read the permissions back rather than assume the mapping is all there is
+	after, err := m.stackTeamPermissions(fresh, destOrg, destProject, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := structpb.NewStruct(map[string]interface{}{
+		"stack_id":             id,
+		"destination_org":      destOrg,
+		"destination_stack_id": stackID(destProject, stackName),
+		"permissions_before":   before,
+		"permissions_after":    after,
+		"failures":             failures,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transfer report: %w", err)
+	}
+
+	return &actionResult{status: actionStatus(failures), response: response}, nil
+}
+
+// stackTeamPermissions returns the permission slug each team of an organization holds on a stack
+func (m *actionManager) stackTeamPermissions(ctx context.Context, orgName, projectName, stackName string) (map[string]interface{}, error) {
+	teams, err := m.client.ListTeams(ctx, orgName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	rv := make(map[string]interface{})
+	for _, summary := range teams {
+		team, err := m.client.GetTeam(ctx, orgName, summary.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team: %w", err)
+		}
+		for _, stack := range team.Stacks {
+			if stack.ProjectName != projectName || stack.StackName != stackName {
+				continue
+			}
+			if slug, ok := stackPermissionSlugs[stack.Permission]; ok {
+				rv[team.Name] = slug
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+// stackPermissionForSlug returns the Pulumi permission level of a stack entitlement slug
+func stackPermissionForSlug(slug string) (int, bool) {
+	for permission, s := range stackPermissionSlugs {
+		if s == slug {
+			return permission, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func transferArgs(t *testing.T, fields map[string]interface{}) *structpb.Struct {
+	t.Helper()
+
+	args, err := structpb.NewStruct(fields)
+	if err != nil {
+		t.Fatalf("failed to build arguments: %v", err)
+	}
+	return args
+}
+
+func TestTransferStackValidatesDestination(t *testing.T) {
+	stack := jsonResponse(`{"orgName":"acme","projectName":"infra","stackName":"prod"}`)
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		routes  map[string]http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name:    "invalid project name",
+			args:    map[string]interface{}{"stack_id": "infra/prod", "destination_project": "new project"},
+			wantErr: "invalid destination project",
+		},
+		{
+			name: "destination stack exists",
+			args: map[string]interface{}{"stack_id": "infra/prod", "destination_project": "platform"},
+			routes: map[string]http.HandlerFunc{
+				"GET /api/stacks/acme/infra/prod":    stack,
+				"GET /api/orgs/acme":                 jsonResponse(`{"githubLogin":"acme"}`),
+				"GET /api/stacks/acme/platform/prod": stack,
+			},
+			wantErr: "already has a stack named prod",
+		},
+		{
+			name: "destination stack unreadable",
+			args: map[string]interface{}{"stack_id": "infra/prod", "destination_project": "platform"},
+			routes: map[string]http.HandlerFunc{
+				"GET /api/stacks/acme/infra/prod":    stack,
+				"GET /api/orgs/acme":                 jsonResponse(`{"githubLogin":"acme"}`),
+				"GET /api/stacks/acme/platform/prod": statusResponse(http.StatusForbidden),
+			},
+			wantErr: "destination stack platform/prod",
+		},
+		{
+			name: "unknown team",
+			args: map[string]interface{}{
+				"stack_id":         "infra/prod",
+				"destination_org":  "globex",
+				"team_permissions": map[string]interface{}{"platform": "admin"},
+			},
+			routes: map[string]http.HandlerFunc{
+				"GET /api/stacks/acme/infra/prod": stack,
+				"GET /api/orgs/globex":            jsonResponse(`{"githubLogin":"globex"}`),
+			},
+			wantErr: "team platform in destination organization globex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(tt.routes)
+			m := api.actionManager(t)
+
+			_, err := m.transferStack(context.Background(), transferArgs(t, tt.args))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("transferStack returned %v, want an error containing %q", err, tt.wantErr)
+			}
+			if n := api.called("POST /api/stacks/acme/infra/prod/transfer") + api.called("POST /api/stacks/acme/infra/prod/rename"); n != 0 {
+				t.Errorf("stack was moved %d times despite the failed check", n)
+			}
+		})
+	}
+}
+
+func TestTransferStackReadsPermissionsBack(t *testing.T) {
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/stacks/acme/infra/prod":         jsonResponse(`{"orgName":"acme","projectName":"infra","stackName":"prod"}`),
+		"GET /api/orgs/acme":                      jsonResponse(`{"githubLogin":"acme"}`),
+		"GET /api/orgs/acme/teams":                jsonResponse(`{"teams":[{"name":"platform"}]}`),
+		"GET /api/orgs/acme/teams/platform":       jsonResponse(`{"name":"platform","stacks":[]}`),
+		"POST /api/stacks/acme/infra/prod/rename": statusResponse(http.StatusNoContent),
+	})
+	m := api.actionManager(t)
+
+	// A sync already cached the team without the permission
+	if _, err := m.client.GetTeam(context.Background(), "acme", "platform"); err != nil {
+		t.Fatalf("GetTeam failed: %v", err)
+	}
+
+	// Once the permission is set, the team reports it
+	api.set("PATCH /api/orgs/acme/teams/platform", func(w http.ResponseWriter, _ *http.Request) {
+		api.set("GET /api/orgs/acme/teams/platform", jsonResponse(`{"name":"platform","stacks":[{"projectName":"platform","stackName":"prod","permission":103}]}`))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	result, err := m.transferStack(context.Background(), transferArgs(t, map[string]interface{}{
+		"stack_id":            "infra/prod",
+		"destination_project": "platform",
+		"team_permissions":    map[string]interface{}{"platform": "admin"},
+	}))
+	if err != nil {
+		t.Fatalf("transferStack failed: %v", err)
+	}
+
+	after := result.response.GetFields()["permissions_after"].GetStructValue().AsMap()
+	if after["platform"] != entitlementSlugAdmin {
+		t.Errorf("permissions_after = %v, want platform: admin", after)
+	}
+	if n := api.called("POST /api/stacks/acme/infra/prod/rename"); n != 1 {
+		t.Errorf("stack was renamed %d times, want 1", n)
+	}
+}
@@ -0,0 +1,219 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	configv1 "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// actionHandler runs a custom action and returns its outcome
+type actionHandler func(ctx context.Context, args *structpb.Struct) (*actionResult, error)
+
+type action struct {
+	schema  *v2.BatonActionSchema
+	handler actionHandler
+}
+
+// actionResult is the outcome of an invoked action, kept so its status can be queried later.
+// Actions that are still running provide refresh to check on their progress.
+type actionResult struct {
+	name     string
+	status   v2.BatonActionStatus
+	response *structpb.Struct
+	refresh  func(ctx context.Context) (v2.BatonActionStatus, *structpb.Struct, error)
+}
+
+// actionManager serves the connector's custom actions
+type actionManager struct {
+	client  *client.Client
+	orgName string
+	members *memberIndex
+	scim    *scimGuard
+	actions map[string]action
+
+	resultsMu sync.Mutex
+	results   map[string]*actionResult
+}
+
+var _ connectorbuilder.CustomActionManager = &actionManager{}
+
+func newActionManager(client *client.Client, orgName string, members *memberIndex, scim *scimGuard) *actionManager {
+	m := &actionManager{
+		client:  client,
+		orgName: orgName,
+		members: members,
+		scim:    scim,
+		results: make(map[string]*actionResult),
+	}
+
+	m.actions = map[string]action{
+		actionOffboardUser:  {schema: offboardUserSchema, handler: m.offboardUser},
+		actionLockStack:     {schema: lockStackSchema, handler: m.lockStack},
+		actionUnlockStack:   {schema: unlockStackSchema, handler: m.unlockStack},
+		actionRevokeTokens:  {schema: revokeTokensSchema, handler: m.revokeTokens},
+		actionTransferStack: {schema: transferStackSchema, handler: m.transferStack},
+	}
+
+	return m
+}
+
+// ListActionSchemas returns the schemas of all actions sorted by name
+func (m *actionManager) ListActionSchemas(_ context.Context) ([]*v2.BatonActionSchema, annotations.Annotations, error) {
+	schemas := make([]*v2.BatonActionSchema, 0, len(m.actions))
+	for _, a := range m.actions {
+		schemas = append(schemas, a.schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Name < schemas[j].Name
+	})
+
+	return schemas, nil, nil
+}
+
+// GetActionSchema returns the schema of a single action
+func (m *actionManager) GetActionSchema(_ context.Context, name string) (*v2.BatonActionSchema, annotations.Annotations, error) {
+	a, ok := m.actions[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown action: %s", name)
+	}
+
+	return a.schema, nil, nil
+}
+
+// InvokeAction runs an action and records its result under a new action ID
+func (m *actionManager) InvokeAction(ctx context.Context, name string, args *structpb.Struct) (string, v2.BatonActionStatus, *structpb.Struct, annotations.Annotations, error) {
+	a, ok := m.actions[name]
+	if !ok {
+		return "", v2.BatonActionStatus_BATON_ACTION_STATUS_UNKNOWN, nil, nil, fmt.Errorf("unknown action: %s", name)
+	}
+	if args == nil {
+		args = &structpb.Struct{}
+	}
+
+	result, err := a.handler(ctx, args)
+	if err != nil {
+		return "", v2.BatonActionStatus_BATON_ACTION_STATUS_FAILED, nil, nil, fmt.Errorf("action %s failed: %w", name, err)
+	}
+	result.name = name
+
+	id := uuid.NewString()
+	m.resultsMu.Lock()
+	m.results[id] = result
+	m.resultsMu.Unlock()
+
+	return id, result.status, result.response, nil, nil
+}
+
+// GetActionStatus returns the status and result of a previously invoked action, checking on the
+// progress of actions that are still running
+func (m *actionManager) GetActionStatus(ctx context.Context, id string) (v2.BatonActionStatus, string, *structpb.Struct, annotations.Annotations, error) {
+	m.resultsMu.Lock()
+	result, ok := m.results[id]
+	var current actionResult
+	if ok {
+		current = *result
+	}
+	m.resultsMu.Unlock()
+
+	if !ok {
+		return v2.BatonActionStatus_BATON_ACTION_STATUS_UNKNOWN, "", nil, nil, fmt.Errorf("unknown action ID: %s", id)
+	}
+	if current.status != v2.BatonActionStatus_BATON_ACTION_STATUS_RUNNING || current.refresh == nil {
+		return current.status, current.name, current.response, nil, nil
+	}
+
+	status, response, err := current.refresh(ctx)
+	if err != nil {
+		return current.status, current.name, current.response, nil, fmt.Errorf("failed to check status of action %s: %w", current.name, err)
+	}
+
+	m.resultsMu.Lock()
+	result.status = status
+	result.response = response
+	m.resultsMu.Unlock()
+
+	return status, current.name, response, nil, nil
+}
+
+// stringArgField declares a string argument of an action
+func stringArgField(name, displayName, description string, required bool) *configv1.Field {
+	return &configv1.Field{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		IsRequired:  required,
+		Field:       &configv1.Field_StringField{StringField: &configv1.StringField{}},
+	}
+}
+
+// boolArgField declares an optional boolean argument of an action
+func boolArgField(name, displayName, description string) *configv1.Field {
+	return &configv1.Field{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		Field:       &configv1.Field_BoolField{BoolField: &configv1.BoolField{}},
+	}
+}
+
+// stringArg returns a string argument, failing if a required argument is missing
+func stringArg(args *structpb.Struct, name string, required bool) (string, error) {
+	value, ok := args.GetFields()[name]
+	if !ok || value.GetStringValue() == "" {
+		if required {
+			return "", fmt.Errorf("missing required argument: %s", name)
+		}
+		return "", nil
+	}
+
+	return value.GetStringValue(), nil
+}
+
+// boolArg returns a boolean argument, false when it is missing
+func boolArg(args *structpb.Struct, name string) (bool, error) {
+	value, ok := args.GetFields()[name]
+	if !ok {
+		return false, nil
+	}
+
+	b, ok := value.GetKind().(*structpb.Value_BoolValue)
+	if !ok {
+		return false, fmt.Errorf("argument %s must be a boolean", name)
+	}
+	return b.BoolValue, nil
+}
+
+// stringMapArg returns a map argument whose values must all be strings
+func stringMapArg(args *structpb.Struct, name string) (map[string]string, error) {
+	value, ok := args.GetFields()[name]
+	if !ok || value.GetStructValue() == nil {
+		return nil, nil
+	}
+
+	rv := make(map[string]string, len(value.GetStructValue().GetFields()))
+	for key, v := range value.GetStructValue().GetFields() {
+		s, ok := v.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return nil, fmt.Errorf("argument %s: value of %s must be a string", name, key)
+		}
+		rv[key] = s.StringValue
+	}
+	return rv, nil
+}
+
+// actionStatus returns complete when every step of an action succeeded and failed otherwise
+func actionStatus(failures []interface{}) v2.BatonActionStatus {
+	if len(failures) > 0 {
+		return v2.BatonActionStatus_BATON_ACTION_STATUS_FAILED
+	}
+	return v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE
+}
@@ -0,0 +1,279 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+type agentPoolBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+	agentPools   *agentPoolIndex
+}
+
+var _ connectorbuilder.ResourceSyncer = &agentPoolBuilder{}
+
+// agentPoolIndex keeps the organization's agent pools by ID for the rest of a sync, so that
+// stack listings resolve pool names without listing the pools for every page.
+type agentPoolIndex struct {
+	client  *client.Client
+	orgName string
+
+	mu     sync.Mutex
+	loaded bool
+	list   []client.AgentPool
+	pools  map[string]client.AgentPool
+}
+
+func newAgentPoolIndex(client *client.Client, orgName string) *agentPoolIndex {
+	return &agentPoolIndex{
+		client:  client,
+		orgName: orgName,
+	}
+}
+
+// get returns the agent pools by ID, listing them on first use in a sync
+func (a *agentPoolIndex) get(ctx context.Context) (map[string]client.AgentPool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.load(ctx); err != nil {
+		return nil, err
+	}
+	return a.pools, nil
+}
+
+// all returns the agent pools in the order Pulumi lists them, listing them on first use in a sync
+func (a *agentPoolIndex) all(ctx context.Context) ([]client.AgentPool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.load(ctx); err != nil {
+		return nil, err
+	}
+	return a.list, nil
+}
+
+// load lists the agent pools unless they were listed during this sync. A token or organization
+// without access to Deployments sees no pools, so stacks still sync without their pool names.
+func (a *agentPoolIndex) load(ctx context.Context) error {
+	if a.loaded {
+		return nil
+	}
+
+	pools, err := a.client.ListAgentPools(ctx, a.orgName)
+	switch {
+	case client.IsPermissionDenied(err):
+		ctxzap.Extract(ctx).Warn("token cannot list agent pools, syncing no agent pools", zap.Error(err))
+		pools = nil
+	case err != nil:
+		return fmt.Errorf("failed to list agent pools: %w", err)
+	}
+
+	a.list = pools
+	a.pools = make(map[string]client.AgentPool, len(pools))
+	for _, pool := range pools {
+		a.pools[pool.ID] = pool
+	}
+	a.loaded = true
+
+	return nil
+}
+
+// reset drops the agent pools, so that the next sync lists them again
+func (a *agentPoolIndex) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.loaded = false
+	a.list = nil
+	a.pools = nil
+}
+
+func agentPoolResource(pool client.AgentPool, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"id":          pool.ID,
+		"name":        pool.Name,
+		"description": pool.Description,
+	}
+	if pool.Status != "" {
+		profile["status"] = pool.Status
+	}
+	if created, ok := unixTimestamp(pool.Created); ok {
+		profile["created"] = created.Format(time.RFC3339)
+	}
+	if lastSeen, ok := unixTimestamp(pool.LastSeen); ok {
+		profile["last_seen"] = lastSeen.Format(time.RFC3339)
+	}
+
+	return batonResource.NewAppResource(
+		pool.Name,
+		agentPoolResourceType,
+		pool.ID,
+		[]batonResource.AppTraitOption{
+			batonResource.WithAppProfile(profile),
+		},
+		batonResource.WithParentResourceID(parentResourceId),
+		batonResource.WithDescription(pool.Description),
+		batonResource.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: agentPoolTokenResourceType.Id}),
+	)
+}
+
+func (o *agentPoolBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return agentPoolResourceType
+}
+
+// List returns the self-hosted deployment agent pools of the organization.
+func (o *agentPoolBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	orgParentID := &v2.ResourceId{
+		ResourceType: orgResourceType.Id,
+		Resource:     o.orgName,
+	}
+
+	pools, err := o.agentPools.all(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	resources := make([]*v2.Resource, 0, len(pools))
+	for _, pool := range pools {
+		resource, err := agentPoolResource(pool, orgParentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// Entitlements returns an empty list since agent pools don't have entitlements
+func (o *agentPoolBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns an empty list since agent pools don't have entitlements
+func (o *agentPoolBuilder) Grants(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newAgentPoolBuilder(client *client.Client, orgName string, agentPools *agentPoolIndex) *agentPoolBuilder {
+	return &agentPoolBuilder{
+		resourceType: agentPoolResourceType,
+		client:       client,
+		orgName:      orgName,
+		agentPools:   agentPools,
+	}
+}
+
+type agentPoolTokenBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+	members      *memberIndex
+	staleness    *tokenStaleness
+}
+
+var _ connectorbuilder.ResourceSyncer = &agentPoolTokenBuilder{}
+
+func agentPoolTokenResource(token client.AgentPoolToken, createdBy string, staleness *tokenStaleness, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	var traitOpts []batonResource.SecretTraitOption
+	if created, ok := unixTimestamp(token.Created); ok {
+		traitOpts = append(traitOpts, batonResource.WithSecretCreatedAt(created))
+	}
+	if lastUsed, ok := unixTimestamp(token.LastUsed); ok {
+		traitOpts = append(traitOpts, batonResource.WithSecretLastUsedAt(lastUsed))
+	}
+	if expires, ok := unixTimestamp(token.Expires); ok {
+		traitOpts = append(traitOpts, batonResource.WithSecretExpiresAt(expires))
+	}
+	if createdBy != "" {
+		traitOpts = append(traitOpts, batonResource.WithSecretCreatedByID(&v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     createdBy,
+		}))
+	}
+
+	name := token.Description
+	if name == "" {
+		name = token.ID
+	}
+
+	stalenessTraitOpts, opts, err := staleness.options(token.Created, token.LastUsed)
+	if err != nil {
+		return nil, err
+	}
+	traitOpts = append(traitOpts, stalenessTraitOpts...)
+	opts = append(opts, batonResource.WithParentResourceID(parentResourceId))
+
+	return batonResource.NewSecretResource(
+		name,
+		agentPoolTokenResourceType,
+		token.ID,
+		traitOpts,
+		opts...,
+	)
+}
+
+func (o *agentPoolTokenBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return agentPoolTokenResourceType
+}
+
+// List returns the access tokens of an agent pool. Tokens are only listed as children of their pool.
+func (o *agentPoolTokenBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil || parentResourceID.ResourceType != agentPoolResourceType.Id {
+		return nil, "", nil, nil
+	}
+
+	pool, err := o.client.GetAgentPool(ctx, o.orgName, parentResourceID.Resource)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to get agent pool: %w", err)
+	}
+
+	resources := make([]*v2.Resource, 0, len(pool.Tokens))
+	for _, token := range pool.Tokens {
+		createdBy, err := o.members.loginPrincipalID(ctx, token.CreatedBy)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resource, err := agentPoolTokenResource(token, createdBy, o.staleness, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// Entitlements returns an empty list since tokens don't have entitlements
+func (o *agentPoolTokenBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns an empty list since tokens don't have entitlements
+func (o *agentPoolTokenBuilder) Grants(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newAgentPoolTokenBuilder(client *client.Client, orgName string, members *memberIndex, staleness *tokenStaleness) *agentPoolTokenBuilder {
+	return &agentPoolTokenBuilder{
+		resourceType: agentPoolTokenResourceType,
+		client:       client,
+		orgName:      orgName,
+		members:      members,
+		staleness:    staleness,
+	}
+}
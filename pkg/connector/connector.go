@@ -1,55 +1,367 @@
 package connector
 
 import (
+	"bytes"
 	"context"
+	_ "embed"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Connector implements the Pulumi connector
 type Connector struct {
-	client  *client.Client
-	orgName string
+	client       *client.Client
+	orgName      string
+	principalKey string
+	scimOverride bool
+	provisioning bool
+	members      *memberIndex
+	scim         *scimGuard
+	actions      *actionManager
+
+	tokenStaleDays int
+	tokenStaleness *tokenStaleness
+
+	stackIncludeTags []string
+	stackExcludeTags []string
+	stackTagFilter   *stackTagFilter
+
+	teamFetchWorkers int
+	teams            *client.TeamPrefetcher
+
+	agentPools *agentPoolIndex
+	saml       *samlIndex
+
+	incrementalSync bool
+	incremental     *incrementalSync
+}
+
+// Option configures optional connector behavior
+type Option func(*Connector)
+
+// WithPrincipalKey sets whether user resources are keyed by login or email
+func WithPrincipalKey(principalKey string) Option {
+	return func(c *Connector) {
+		if principalKey != "" {
+			c.principalKey = principalKey
+		}
+	}
+}
+
+// WithSCIMOverride allows revoking access that is managed by SCIM
+func WithSCIMOverride(override bool) Option {
+	return func(c *Connector) {
+		c.scimOverride = override
+	}
+}
+
+// WithProvisioning records whether provisioning is enabled, which makes Validate require a token
+// with admin rights
+func WithProvisioning(enabled bool) Option {
+	return func(c *Connector) {
+		c.provisioning = enabled
+	}
+}
+
+// WithStackTagFilter limits the synced stacks to those matching any include expression and no
+// exclude expression. Expressions are "key" or "key=pattern".
+func WithStackTagFilter(include, exclude []string) Option {
+	return func(c *Connector) {
+		c.stackIncludeTags = include
+		c.stackExcludeTags = exclude
+	}
+}
+
+// WithTokenStaleDays sets how many days an access token may go unused before it is marked stale.
+// Zero disables the check.
+func WithTokenStaleDays(days int) Option {
+	return func(c *Connector) {
+		c.tokenStaleDays = days
+	}
+}
+
+// WithTeamFetchWorkers sets how many teams are fetched at once when a sync loads team details.
+// Zero uses client.DefaultTeamFetchWorkers.
+func WithTeamFetchWorkers(workers int) Option {
+	return func(c *Connector) {
+		c.teamFetchWorkers = workers
+	}
+}
+
+// WithIncrementalSync makes syncs reuse the team and stack grants of the previous sync when the
+// audit log shows no change to them since
+func WithIncrementalSync(enabled bool) Option {
+	return func(c *Connector) {
+		c.incrementalSync = enabled
+	}
+}
+
+// RegisterActionManager returns the manager for the connector's custom actions
+func (c *Connector) RegisterActionManager(_ context.Context) (connectorbuilder.CustomActionManager, error) {
+	return c.actions, nil
+}
+
+// FinishSync ends a sync: it drops the state kept for the sync and finishes the client's sync, so
+// that the event feed and actions run between syncs read current data
+func (c *Connector) FinishSync() {
+	c.teams.Reset()
+	c.agentPools.reset()
+	c.saml.reset()
+	c.scim.reset()
+	c.members.reset()
+	c.incremental.reset()
+	c.client.FinishSync()
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced
 func (c *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
 	return []connectorbuilder.ResourceSyncer{
-		newOrgBuilder(c.client, c.orgName),
-		newUserBuilder(c.client, c.orgName),
-		newTeamBuilder(c.client, c.orgName),
+		newOrgBuilder(c.client, c.orgName, c.members, c.scim),
+		newUserBuilder(c.client, c.orgName, c.principalKey, c.scim),
+		newTeamBuilder(c.client, c.orgName, c.members, c.scim, c.teams, c.incremental, c.saml),
+		newWebhookBuilder(c.client, c.orgName, c.stackTagFilter),
+		newProjectBuilder(c.client, c.orgName),
+		newStackBuilder(c.client, c.orgName, c.stackTagFilter, c.members, c.teams, c.agentPools, c.incremental),
+		newAgentPoolBuilder(c.client, c.orgName, c.agentPools),
+		newAgentPoolTokenBuilder(c.client, c.orgName, c.members, c.tokenStaleness),
+		newAccessTokenBuilder(c.client, c.orgName, c.members, c.tokenStaleness),
+		newOIDCIssuerBuilder(c.client, c.orgName),
+		newPolicyPackBuilder(c.client, c.orgName),
+		newPolicyGroupBuilder(c.client, c.orgName, c.stackTagFilter),
 	}
 }
 
+const (
+	logoAssetID       = "logo"
+	avatarAssetPrefix = "avatar:"
+)
+
+//go:embed assets/pulumi-logo.svg
+var pulumiLogo []byte
+
 // Asset returns asset data for the connector
 func (c *Connector) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.ReadCloser, error) {
-	return "", nil, nil
+	if asset == nil || asset.Id == "" {
+		return "", nil, fmt.Errorf("asset reference is empty")
+	}
+
+	if asset.Id == logoAssetID {
+		return "image/svg+xml", io.NopCloser(bytes.NewReader(pulumiLogo)), nil
+	}
+	if !strings.HasPrefix(asset.Id, avatarAssetPrefix) {
+		return "", nil, fmt.Errorf("unknown asset: %s", asset.Id)
+	}
+
+	// Avatars are referenced by login and their URL is looked up from the member list, so
+	// callers cannot make the connector fetch arbitrary URLs
+	login := strings.TrimPrefix(asset.Id, avatarAssetPrefix)
+	member, err := c.members.find(ctx, login, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if member == nil {
+		return "", nil, fmt.Errorf("no member of organization %s has login %s", c.orgName, login)
+	}
+	if member.User.AvatarUrl == "" {
+		return "", nil, fmt.Errorf("member %s has no avatar", login)
+	}
+
+	contentType, data, err := c.client.GetAsset(ctx, member.User.AvatarUrl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return contentType, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// avatarAssetRef returns a reference to a user's avatar that can be served by Asset
+func avatarAssetRef(user client.UserInfo) *v2.AssetRef {
+	if user.AvatarUrl == "" || user.GithubLogin == "" {
+		return nil
+	}
+	return &v2.AssetRef{Id: avatarAssetPrefix + user.GithubLogin}
 }
 
 // Metadata returns metadata about the connector
 func (c *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
+	fields := map[string]interface{}{
+		"org_name":      c.orgName,
+		"principal_key": c.principalKey,
+	}
+
+	// Metadata is also requested without valid credentials, e.g. to report capabilities,
+	// so the token identity is best effort here and enforced by Validate
+	identity, err := c.client.GetCurrentUser(ctx)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("failed to look up access token identity", zap.Error(err))
+	} else {
+		orgs := make([]interface{}, 0, len(identity.OrganizationNames()))
+		for _, name := range identity.OrganizationNames() {
+			orgs = append(orgs, name)
+		}
+
+		fields["token_owner"] = identity.GithubLogin
+		fields["token_type"] = identity.TokenType()
+		fields["organizations"] = orgs
+	}
+
+	profile, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build connector profile: %w", err)
+	}
+
 	return &v2.ConnectorMetadata{
-		DisplayName: "Pulumi Cloud",
+		DisplayName:           "Pulumi Cloud",
+		Description:           "Syncs users, teams, projects, stacks, access tokens, webhooks, deployment agent pools, OIDC issuers and policies from Pulumi Cloud, and provisions organization roles, team membership, stack permissions and policy group membership.",
+		HelpUrl:               "https://github.com/conductorone/baton-pulumi-cloud",
+		Icon:                  &v2.AssetRef{Id: logoAssetID},
+		Logo:                  &v2.AssetRef{Id: logoAssetID},
+		Profile:               profile,
+		AccountCreationSchema: accountCreationSchema(),
 	}, nil
 }
 
+// accountCreationSchema describes the fields needed to invite a user to the organization
+func accountCreationSchema() *v2.ConnectorAccountCreationSchema {
+	defaultRole := roleMember
+
+	return &v2.ConnectorAccountCreationSchema{
+		FieldMap: map[string]*v2.ConnectorAccountCreationSchema_Field{
+			"email": {
+				DisplayName: "Email",
+				Required:    true,
+				Description: "The email address the organization invite is sent to.",
+				Placeholder: "user@example.com",
+				Order:       1,
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+				},
+			},
+			"role": {
+				DisplayName: "Role",
+				Required:    false,
+				Description: "The initial organization role, either member or admin.",
+				Placeholder: roleMember,
+				Order:       2,
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{
+						DefaultValue: &defaultRole,
+					},
+				},
+			},
+			"teams": {
+				DisplayName: "Teams",
+				Required:    false,
+				Description: "The names of the teams the user is added to once they accept the invite.",
+				Placeholder: "platform",
+				Order:       3,
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringListField{
+					StringListField: &v2.ConnectorAccountCreationSchema_StringListField{},
+				},
+			},
+		},
+	}
+}
+
 // Validate ensures the connector is properly configured
 func (c *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
-	// Test the connection by trying to list users
-	_, err := c.client.ListUsers(ctx, c.orgName, "")
+	l := ctxzap.Extract(ctx)
+
+	identity, err := c.client.GetCurrentUser(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to look up access token identity: %w", err)
+	}
+
+	tokenType := identity.TokenType()
+	l.Info("pulumi access token identity",
+		zap.String("token_owner", identity.GithubLogin),
+		zap.String("token_type", tokenType),
+		zap.Strings("organizations", identity.OrganizationNames()),
+	)
+
+	if !identity.CanAccessOrg(c.orgName) {
+		return nil, fmt.Errorf(
+			"organization %q is not accessible with the %s access token owned by %q (accessible organizations: %s)",
+			c.orgName,
+			tokenType,
+			identity.GithubLogin,
+			strings.Join(identity.OrganizationNames(), ", "),
+		)
+	}
+
+	// Syncing works without admin rights, so they are only required when provisioning
+	isAdmin, err := c.tokenIsOrgAdmin(ctx, identity)
+	if err != nil {
+		if c.provisioning {
+			return nil, err
+		}
+		l.Warn("failed to check whether the access token has admin rights", zap.Error(err))
+		return nil, nil
+	}
+	if !isAdmin {
+		if c.provisioning {
+			return nil, fmt.Errorf(
+				"the %s access token owned by %q does not have admin rights in organization %q, which are required for provisioning",
+				tokenType,
+				identity.GithubLogin,
+				c.orgName,
+			)
+		}
+		l.Warn("the access token does not have admin rights, provisioning and some resources will not be available",
+			zap.String("token_owner", identity.GithubLogin),
+			zap.String("token_type", tokenType),
+		)
 	}
+
 	return nil, nil
 }
 
+// tokenIsOrgAdmin reports whether the access token can administer the configured organization
+func (c *Connector) tokenIsOrgAdmin(ctx context.Context, identity *client.CurrentUser) (bool, error) {
+	switch identity.TokenType() {
+	case client.TokenTypeOrganization:
+		return true, nil
+	case client.TokenTypeTeam:
+		// Team tokens carry the organization role assigned to their team
+		team, err := c.client.GetTeam(ctx, c.orgName, identity.TokenInfo.Team)
+		if err != nil {
+			return false, err
+		}
+		return team.IsAdmin(), nil
+	}
+
+	// Personal tokens carry the role of their owner, so look the owner up in the member list
+	var token string
+	for {
+		resp, err := c.client.ListUsers(ctx, c.orgName, token)
+		if err != nil {
+			return false, fmt.Errorf("failed to list org members: %w", err)
+		}
+
+		for _, member := range resp.Members {
+			if member.User.GithubLogin == identity.GithubLogin {
+				return member.Role == roleAdmin, nil
+			}
+		}
+
+		if resp.ContinuationToken == "" {
+			return false, nil
+		}
+		token = resp.ContinuationToken
+	}
+}
+
 // New returns a new instance of the connector
-func New(ctx context.Context, client *client.Client, orgName string) (*Connector, error) {
+func New(ctx context.Context, client *client.Client, orgName string, opts ...Option) (*Connector, error) {
 	if client == nil {
 		return nil, fmt.Errorf("pulumi client not provided")
 	}
@@ -58,8 +370,44 @@ func New(ctx context.Context, client *client.Client, orgName string) (*Connector
 		return nil, fmt.Errorf("organization name not provided")
 	}
 
-	return &Connector{
-		client:  client,
-		orgName: orgName,
-	}, nil
+	c := &Connector{
+		client:         client,
+		orgName:        orgName,
+		principalKey:   PrincipalKeyLogin,
+		tokenStaleDays: DefaultTokenStaleDays,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch c.principalKey {
+	case PrincipalKeyLogin, PrincipalKeyEmail:
+	default:
+		return nil, fmt.Errorf("unknown principal key: %s", c.principalKey)
+	}
+
+	stackTagFilter, err := newStackTagFilter(c.stackIncludeTags, c.stackExcludeTags)
+	if err != nil {
+		return nil, err
+	}
+	c.stackTagFilter = stackTagFilter
+
+	if c.tokenStaleDays < 0 {
+		return nil, fmt.Errorf("token stale days must not be negative: %d", c.tokenStaleDays)
+	}
+	c.tokenStaleness = newTokenStaleness(c.tokenStaleDays)
+
+	if c.teamFetchWorkers < 0 {
+		return nil, fmt.Errorf("team fetch workers must not be negative: %d", c.teamFetchWorkers)
+	}
+	c.teams = client.NewTeamPrefetcher(orgName, c.teamFetchWorkers)
+
+	c.agentPools = newAgentPoolIndex(client, orgName)
+	c.saml = newSAMLIndex(client, orgName)
+	c.incremental = newIncrementalSync(client, orgName, c.incrementalSync)
+	c.members = newMemberIndex(client, orgName, c.principalKey)
+	c.scim = newSCIMGuard(client, orgName, c.scimOverride)
+	c.actions = newActionManager(client, orgName, c.members, c.scim)
+
+	return c, nil
 }
@@ -0,0 +1,55 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestFinishSyncResetsSyncState(t *testing.T) {
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/orgs/acme/teams":      jsonResponse(`{"teams": [{"name": "devs"}]}`),
+		"GET /api/orgs/acme/teams/devs": jsonResponse(`{"name": "devs", "members": [{"githubLogin": "alice"}]}`),
+		"GET /api/orgs/acme/settings":   jsonResponse(`{"scimEnabled": false}`),
+		"GET /api/orgs/acme/saml":       statusResponse(http.StatusNotFound),
+		membersRoute:                    jsonResponse(`{"members": [{"role": "member", "user": {"githubLogin": "alice"}}]}`),
+	})
+	c, err := New(context.Background(), api.client(t), "acme")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	readSyncState := func() {
+		t.Helper()
+
+		if _, err := c.teams.GetTeam(ctx, "devs"); err != nil {
+			t.Fatalf("GetTeam failed: %v", err)
+		}
+		if _, err := c.saml.get(ctx, false); err != nil {
+			t.Fatalf("reading the SAML configuration failed: %v", err)
+		}
+		if _, err := c.members.find(ctx, "alice", false); err != nil {
+			t.Fatalf("find failed: %v", err)
+		}
+		c.scim.isEnabled(ctx, false)
+	}
+
+	readSyncState()
+	readSyncState()
+	routes := []string{"GET /api/orgs/acme/teams/devs", "GET /api/orgs/acme/saml", membersRoute, "GET /api/orgs/acme/settings"}
+	for _, route := range routes {
+		if n := api.called(route); n != 1 {
+			t.Errorf("%s requested %d times during one sync, want 1", route, n)
+		}
+	}
+
+	// The next sync, and anything run between syncs, reads everything again
+	c.FinishSync()
+	readSyncState()
+	for _, route := range routes {
+		if n := api.called(route); n != 2 {
+			t.Errorf("%s requested %d times over two syncs, want 2", route, n)
+		}
+	}
+}
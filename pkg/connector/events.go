@@ -0,0 +1,155 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultEventLookback bounds how far back update history is read when no start is given
+	defaultEventLookback = 30 * 24 * time.Hour
+	stackUpdatesPageSize = 100
+)
+
+var _ connectorbuilder.EventProvider = &Connector{}
+
+// eventCursor tracks the event feed's walk over the organization's stacks. Each page of events
+// covers the update history of one stack.
+type eventCursor struct {
+	StacksToken string `json:"stacksToken,omitempty"`
+	Index       int    `json:"index"`
+}
+
+// ListEvents emits a usage event for every stack update since earliestEvent, with the user who
+// requested the update as actor and the stack as target, so stack permissions get last-used data.
+func (c *Connector) ListEvents(ctx context.Context, earliestEvent *timestamppb.Timestamp, pToken *pagination.StreamToken) ([]*v2.Event, *pagination.StreamState, annotations.Annotations, error) {
+	since := time.Now().Add(-defaultEventLookback)
+	if earliestEvent != nil {
+		since = earliestEvent.AsTime()
+	}
+
+	var cursor eventCursor
+	if pToken != nil && pToken.Cursor != "" {
+		if err := json.Unmarshal([]byte(pToken.Cursor), &cursor); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid event cursor: %w", err)
+		}
+	}
+
+	stacks, err := c.client.ListStacks(ctx, c.orgName, cursor.StacksToken)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	var events []*v2.Event
+	if cursor.Index < len(stacks.Stacks) {
+		events, err = c.stackUpdateEvents(ctx, stacks.Stacks[cursor.Index], since)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	next := eventCursor{StacksToken: cursor.StacksToken, Index: cursor.Index + 1}
+	if next.Index >= len(stacks.Stacks) {
+		if stacks.ContinuationToken == "" {
+			return events, &pagination.StreamState{}, nil, nil
+		}
+		next = eventCursor{StacksToken: stacks.ContinuationToken}
+	}
+
+	nextCursor, err := json.Marshal(next)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to encode event cursor: %w", err)
+	}
+	return events, &pagination.StreamState{Cursor: string(nextCursor), HasMore: true}, nil, nil
+}
+
+// stackUpdateEvents returns usage events for the updates of a stack that started after since
+func (c *Connector) stackUpdateEvents(ctx context.Context, stack client.Stack, since time.Time) ([]*v2.Event, error) {
+	included, err := c.stackTagFilter.includesStack(ctx, c.client, c.orgName, stack.ProjectName, stack.StackName)
+	if err != nil || !included {
+		return nil, err
+	}
+
+	id := stackID(stack.ProjectName, stack.StackName)
+	target := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: stackResourceType.Id,
+			Resource:     id,
+		},
+		DisplayName: id,
+	}
+
+	var events []*v2.Event
+	for page := 1; ; page++ {
+		resp, err := c.client.ListStackUpdates(ctx, c.orgName, stack.ProjectName, stack.StackName, page, stackUpdatesPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, update := range resp.Updates {
+			started := time.Unix(update.Info.StartTime, 0)
+			if started.Before(since) {
+				// Updates are listed newest first, so the rest are older still
+				return events, nil
+			}
+			if update.RequestedBy.GithubLogin == "" {
+				continue
+			}
+
+			event, err := c.stackUpdateEvent(ctx, target, update, started)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+
+		if len(resp.Updates) < stackUpdatesPageSize {
+			return events, nil
+		}
+	}
+}
+
+func (c *Connector) stackUpdateEvent(ctx context.Context, target *v2.Resource, update client.StackUpdate, started time.Time) (*v2.Event, error) {
+	userID, err := c.members.principalID(ctx, update.RequestedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := structpb.NewStruct(map[string]interface{}{
+		"kind":    update.Info.Kind,
+		"result":  update.Info.Result,
+		"version": update.Version,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build event details: %w", err)
+	}
+
+	return &v2.Event{
+		Id:         "stack-update:" + target.Id.Resource + ":" + strconv.Itoa(update.Version),
+		OccurredAt: timestamppb.New(started),
+		Event: &v2.Event_UsageEvent{
+			UsageEvent: &v2.UsageEvent{
+				TargetResource: target,
+				ActorResource: &v2.Resource{
+					Id: &v2.ResourceId{
+						ResourceType: userResourceType.Id,
+						Resource:     userID,
+					},
+					DisplayName: update.RequestedBy.Name,
+				},
+			},
+		},
+		Annotations: annotations.New(details),
+	}, nil
+}
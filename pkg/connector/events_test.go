@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestListEventsWalksStacks(t *testing.T) {
+	now := time.Now().Unix()
+	updates := func(version int, login string) http.HandlerFunc {
+		return jsonResponse(fmt.Sprintf(`{"updates": [
+			{"info": {"kind": "update", "startTime": %d, "result": "succeeded"}, "version": %d, "requestedBy": {"name": "%s", "githubLogin": "%s"}},
+			{"info": {"kind": "update", "startTime": %d, "result": "succeeded"}, "version": 1, "requestedBy": {"githubLogin": "old"}}
+		]}`, now, version, login, login, now-int64(60*24*time.Hour/time.Second)))
+	}
+
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/user/stacks": func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("continuationToken") {
+			case "":
+				jsonResponse(`{"stacks": [
+					{"projectName": "web", "stackName": "dev"},
+					{"projectName": "web", "stackName": "prod"}
+				], "continuationToken": "page2"}`)(w, r)
+			case "page2":
+				jsonResponse(`{"stacks": [{"projectName": "api", "stackName": "prod"}]}`)(w, r)
+			default:
+				t.Errorf("unexpected continuation token %q", r.URL.Query().Get("continuationToken"))
+				http.NotFound(w, r)
+			}
+		},
+		"GET /api/stacks/acme/web/dev/updates":  updates(3, "alice"),
+		"GET /api/stacks/acme/web/prod/updates": updates(7, "departed"),
+		"GET /api/stacks/acme/api/prod/updates": updates(2, "alice"),
+		membersRoute:                            jsonResponse(`{"members": [{"role": "member", "user": {"githubLogin": "alice", "email": "alice@example.com"}}]}`),
+	})
+	c := api.client(t)
+	conn := &Connector{
+		client:       c,
+		orgName:      "acme",
+		principalKey: PrincipalKeyEmail,
+		members:      newMemberIndex(c, "acme", PrincipalKeyEmail),
+	}
+
+	ctx := context.Background()
+	earliest := timestamppb.New(time.Now().Add(-time.Hour))
+	token := &pagination.StreamToken{}
+
+	var got []string
+	var cursors []eventCursor
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("event stream did not end")
+		}
+
+		events, state, _, err := conn.ListEvents(ctx, earliest, token)
+		if err != nil {
+			t.Fatalf("ListEvents failed: %v", err)
+		}
+		for _, event := range events {
+			usage := event.GetUsageEvent()
+			got = append(got, event.Id+" by "+usage.ActorResource.Id.Resource)
+		}
+		if !state.HasMore {
+			break
+		}
+
+		var cursor eventCursor
+		if err := json.Unmarshal([]byte(state.Cursor), &cursor); err != nil {
+			t.Fatalf("invalid cursor %q: %v", state.Cursor, err)
+		}
+		cursors = append(cursors, cursor)
+		token = &pagination.StreamToken{Cursor: state.Cursor}
+	}
+
+	want := []string{
+		"stack-update:web/dev:3 by alice@example.com",
+		"stack-update:web/prod:7 by departed",
+		"stack-update:api/prod:2 by alice@example.com",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	wantCursors := []eventCursor{{Index: 1}, {StacksToken: "page2"}}
+	if fmt.Sprint(cursors) != fmt.Sprint(wantCursors) {
+		t.Errorf("cursors = %+v, want %+v", cursors, wantCursors)
+	}
+}
+
+func TestListEventsRejectsInvalidCursor(t *testing.T) {
+	conn := &Connector{orgName: "acme"}
+
+	_, _, _, err := conn.ListEvents(context.Background(), nil, &pagination.StreamToken{Cursor: "not json"})
+	if err == nil {
+		t.Fatal("ListEvents accepted an invalid cursor")
+	}
+}
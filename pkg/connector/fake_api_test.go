@@ -0,0 +1,98 @@
+package connector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+)
+
+// fakeAPI serves canned Pulumi API responses keyed by "METHOD /api/path" and records every
+// request it receives. Unknown routes answer 404.
+type fakeAPI struct {
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests []string
+}
+
+func newFakeAPI(routes map[string]http.HandlerFunc) *fakeAPI {
+	if routes == nil {
+		routes = make(map[string]http.HandlerFunc)
+	}
+	return &fakeAPI{routes: routes}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + r.URL.Path
+
+	f.mu.Lock()
+	f.requests = append(f.requests, route)
+	handler, ok := f.routes[route]
+	f.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
+
+// set replaces the handler of route, e.g. to reflect a change made through the API
+func (f *fakeAPI) set(route string, handler http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.routes[route] = handler
+}
+
+// called reports how many requests were made to route
+func (f *fakeAPI) called(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, request := range f.requests {
+		if request == route {
+			n++
+		}
+	}
+	return n
+}
+
+// client returns a Pulumi client talking to the fake API
+func (f *fakeAPI) client(t *testing.T) *client.Client {
+	t.Helper()
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	c, err := client.NewClient("pul-test", client.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return c
+}
+
+// actionManager returns an action manager for organization acme backed by the fake API
+func (f *fakeAPI) actionManager(t *testing.T) *actionManager {
+	t.Helper()
+
+	c := f.client(t)
+	return newActionManager(c, "acme", newMemberIndex(c, "acme", PrincipalKeyLogin), newSCIMGuard(c, "acme", false))
+}
+
+func jsonResponse(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}
+}
+
+func statusResponse(code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(code)
+	}
+}
@@ -0,0 +1,254 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// incrementalMaxAge is how old the cursor recorded with a resource's grants may get before they
+// are fetched again. Pulumi only keeps a limited audit-log history, and replaying a long window
+// costs about as much as fetching from scratch.
+const incrementalMaxAge = 7 * 24 * time.Hour
+
+// changeScope is the set of grants an audit-log event may have changed
+type changeScope uint8
+
+const (
+	changesTeams changeScope = 1 << iota
+	changesStacks
+
+	changesNone changeScope = 0
+	changesAll              = changesTeams | changesStacks
+)
+
+// auditEventScopes maps audit-log event names to the grants they may change. Events not listed
+// here may change anything, so they make every team and stack fetch its grants again.
+var auditEventScopes = map[string]changeScope{
+	// Memberships of a team
+	"team-created":        changesNone,
+	"team-member-added":   changesTeams,
+	"team-member-removed": changesTeams,
+	"team-member-updated": changesTeams,
+	// A deleted team loses its stack permissions along with its members
+	"team-deleted": changesTeams | changesStacks,
+
+	// Permissions of teams and users on stacks
+	"team-stack-permission-added":   changesStacks,
+	"team-stack-permission-removed": changesStacks,
+	"team-stack-permission-updated": changesStacks,
+	"stack-collaborator-added":      changesStacks,
+	"stack-collaborator-removed":    changesStacks,
+	"stack-collaborator-updated":    changesStacks,
+	"stack-created":                 changesStacks,
+	"stack-deleted":                 changesStacks,
+	"stack-renamed":                 changesStacks,
+	"stack-transferred":             changesStacks,
+	"stack-update-started":          changesNone,
+	"stack-update-completed":        changesNone,
+	"stack-preview-started":         changesNone,
+	"stack-refresh-started":         changesNone,
+	"stack-destroy-started":         changesNone,
+	"stack-import-started":          changesNone,
+	"stack-tag-added":               changesNone,
+	"stack-tag-removed":             changesNone,
+	"stack-tag-updated":             changesNone,
+	"stack-webhook-created":         changesNone,
+	"stack-webhook-deleted":         changesNone,
+
+	// Leaving the organization also ends team memberships and stack collaborations
+	"member-added":        changesNone,
+	"member-removed":      changesTeams | changesStacks,
+	"member-role-changed": changesNone,
+
+	// Events that change no team or stack access
+	"user-login":            changesNone,
+	"access-token-created":  changesNone,
+	"access-token-deleted":  changesNone,
+	"org-token-created":     changesNone,
+	"org-token-deleted":     changesNone,
+	"team-token-created":    changesNone,
+	"team-token-deleted":    changesNone,
+	"org-webhook-created":   changesNone,
+	"org-webhook-deleted":   changesNone,
+	"policy-pack-published": changesNone,
+	"policy-pack-deleted":   changesNone,
+	"policy-group-created":  changesNone,
+	"policy-group-deleted":  changesNone,
+	"audit-logs-exported":   changesNone,
+	"agent-pool-created":    changesNone,
+	"agent-pool-deleted":    changesNone,
+}
+
+// auditEventScope returns the grants an audit-log event may have changed. Requests refused for
+// lack of authentication changed nothing.
+func auditEventScope(event client.AuditLogEvent) changeScope {
+	if event.AuthFailure {
+		return changesNone
+	}
+	if scope, ok := auditEventScopes[event.Event]; ok {
+		return scope
+	}
+	return changesAll
+}
+
+// grantsState is recorded with the grants of a team or stack: the data they were built from, and
+// the cursor as of which that data is current
+type grantsState struct {
+	Cursor int64           `json:"cursor"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// incrementalSync rebuilds team and stack grants from the data recorded with them by the previous
+// sync when the audit log shows no event since that could have changed them. The data travels in
+// the resource's ETag annotation, which the SDK keeps in the sync and hands back with the next
+// sync's grants request. Members are always listed again, since fields such as the last login
+// change without an audit-log event.
+type incrementalSync struct {
+	client  *client.Client
+	orgName string
+	enabled bool
+	now     func() time.Time
+
+	mu      sync.Mutex
+	started time.Time
+	changes map[int64]*auditChanges
+}
+
+// auditChanges is what the audit log reports since a cursor
+type auditChanges struct {
+	scope changeScope
+	err   error
+}
+
+func newIncrementalSync(client *client.Client, orgName string, enabled bool) *incrementalSync {
+	s := &incrementalSync{
+		client:  client,
+		orgName: orgName,
+		enabled: enabled,
+		now:     time.Now,
+	}
+	s.reset()
+	return s
+}
+
+// reset starts a new sync. Data fetched from now on is recorded as current as of this time, so
+// that events which happened while the sync ran are replayed by the next one.
+func (s *incrementalSync) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.started = s.now()
+	s.changes = nil
+}
+
+// reuse loads the data recorded with the resource's grants into data and reports whether it is
+// still current. Anything in the way of reusing it results in the grants being fetched again.
+func (s *incrementalSync) reuse(ctx context.Context, resource *v2.Resource, scope changeScope, data interface{}) bool {
+	if !s.enabled {
+		return false
+	}
+
+	etag := &v2.ETag{}
+	annos := annotations.Annotations(resource.GetAnnotations())
+	if ok, err := annos.Pick(etag); err != nil || !ok {
+		return false
+	}
+
+	var state grantsState
+	if err := json.Unmarshal([]byte(etag.Value), &state); err != nil {
+		ctxzap.Extract(ctx).Debug("ignoring unreadable incremental state", zap.String("resource", resource.GetId().GetResource()), zap.Error(err))
+		return false
+	}
+
+	cursor := time.Unix(state.Cursor, 0)
+	if s.now().Sub(cursor) > incrementalMaxAge {
+		return false
+	}
+
+	changed, err := s.changedSince(ctx, cursor)
+	if err != nil || changed&scope != 0 {
+		return false
+	}
+
+	return json.Unmarshal(state.Data, data) == nil
+}
+
+// record returns the annotations carrying the data a resource's grants were built from
+func (s *incrementalSync) record(data interface{}) (annotations.Annotations, error) {
+	if !s.enabled {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	cursor := s.started.Unix()
+	s.mu.Unlock()
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode incremental state: %w", err)
+	}
+	value, err := json.Marshal(grantsState{Cursor: cursor, Data: body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode incremental state: %w", err)
+	}
+
+	return annotations.New(&v2.ETag{Value: string(value)}), nil
+}
+
+// changedSince returns the grants that audit-log events since cursor may have changed. The audit
+// log is read once per cursor and sync.
+func (s *incrementalSync) changedSince(ctx context.Context, cursor time.Time) (changeScope, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if changes, ok := s.changes[cursor.Unix()]; ok {
+		return changes.scope, changes.err
+	}
+
+	scope, err := s.readAuditLog(ctx, cursor)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("failed to read the audit log, fetching grants again", zap.Time("cursor", cursor), zap.Error(err))
+	} else {
+		ctxzap.Extract(ctx).Debug("read the audit log for incremental sync",
+			zap.Time("cursor", cursor),
+			zap.Bool("teams_changed", scope&changesTeams != 0),
+			zap.Bool("stacks_changed", scope&changesStacks != 0),
+		)
+	}
+
+	if s.changes == nil {
+		s.changes = make(map[int64]*auditChanges)
+	}
+	s.changes[cursor.Unix()] = &auditChanges{scope: scope, err: err}
+
+	return scope, err
+}
+
+func (s *incrementalSync) readAuditLog(ctx context.Context, since time.Time) (changeScope, error) {
+	until := s.now()
+	scope := changesNone
+	token := ""
+	for {
+		resp, err := s.client.ListAuditLogs(ctx, s.orgName, since, until, token)
+		if err != nil {
+			return changesAll, err
+		}
+
+		for _, event := range resp.AuditLogEvents {
+			scope |= auditEventScope(event)
+		}
+		if scope == changesAll || resp.ContinuationToken == "" {
+			return scope, nil
+		}
+		token = resp.ContinuationToken
+	}
+}
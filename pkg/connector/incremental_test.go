@@ -0,0 +1,253 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+)
+
+const auditLogsRoute = "GET /api/orgs/acme/auditlogs/v2"
+
+func TestAuditEventScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		event client.AuditLogEvent
+		want  changeScope
+	}{
+		{"team membership", client.AuditLogEvent{Event: "team-member-added"}, changesTeams},
+		{"stack collaborator", client.AuditLogEvent{Event: "stack-collaborator-removed"}, changesStacks},
+		{"team stack permission", client.AuditLogEvent{Event: "team-stack-permission-updated"}, changesStacks},
+		{"member leaving", client.AuditLogEvent{Event: "member-removed"}, changesAll},
+		{"team deleted", client.AuditLogEvent{Event: "team-deleted"}, changesAll},
+		{"stack update", client.AuditLogEvent{Event: "stack-update-started"}, changesNone},
+		{"token created", client.AuditLogEvent{Event: "org-token-created"}, changesNone},
+		{"unknown event", client.AuditLogEvent{Event: "something-new"}, changesAll},
+		{"unknown event naming a team", client.AuditLogEvent{Event: "teams-reorganized", Description: "team devs"}, changesAll},
+		{"failed authentication", client.AuditLogEvent{Event: "team-member-added", AuthFailure: true}, changesNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auditEventScope(tt.event); got != tt.want {
+				t.Errorf("auditEventScope(%q) = %b, want %b", tt.event.Event, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordedResource returns a team carrying the state recorded by a sync that started at start
+func recordedResource(t *testing.T, c *client.Client, start time.Time, data interface{}) *v2.Resource {
+	t.Helper()
+
+	prev := newIncrementalSync(c, "acme", true)
+	prev.now = func() time.Time { return start }
+	prev.reset()
+
+	annos, err := prev.record(data)
+	if err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	return &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: teamResourceType.Id, Resource: "devs"},
+		Annotations: annos,
+	}
+}
+
+func TestIncrementalSyncReuse(t *testing.T) {
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	recorded := &client.Team{Name: "devs", Members: []client.UserInfo{{GithubLogin: "alice"}}}
+
+	tests := []struct {
+		name      string
+		disabled  bool
+		resource  func(t *testing.T, c *client.Client) *v2.Resource
+		audit     http.HandlerFunc
+		elapsed   time.Duration
+		scope     changeScope
+		want      bool
+		wantAudit int
+	}{
+		{
+			name:      "no changes",
+			audit:     jsonResponse(`{"auditLogEvents": [{"event": "stack-update-started"}, {"event": "user-login"}]}`),
+			scope:     changesTeams,
+			want:      true,
+			wantAudit: 1,
+		},
+		{
+			name:      "changes to other resources",
+			audit:     jsonResponse(`{"auditLogEvents": [{"event": "stack-collaborator-added"}]}`),
+			scope:     changesTeams,
+			want:      true,
+			wantAudit: 1,
+		},
+		{
+			name:      "changes to the resource kind",
+			audit:     jsonResponse(`{"auditLogEvents": [{"event": "team-member-removed"}]}`),
+			scope:     changesTeams,
+			wantAudit: 1,
+		},
+		{
+			name:      "unknown event",
+			audit:     jsonResponse(`{"auditLogEvents": [{"event": "something-new"}]}`),
+			scope:     changesTeams,
+			wantAudit: 1,
+		},
+		{
+			name:      "audit log not readable",
+			audit:     statusResponse(http.StatusForbidden),
+			scope:     changesTeams,
+			wantAudit: 1,
+		},
+		{
+			name:    "expired cursor",
+			audit:   jsonResponse(`{"auditLogEvents": []}`),
+			elapsed: incrementalMaxAge + time.Hour,
+			scope:   changesTeams,
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			audit:    jsonResponse(`{"auditLogEvents": []}`),
+			scope:    changesTeams,
+		},
+		{
+			name: "nothing recorded",
+			resource: func(t *testing.T, c *client.Client) *v2.Resource {
+				return &v2.Resource{Id: &v2.ResourceId{ResourceType: teamResourceType.Id, Resource: "devs"}}
+			},
+			audit: jsonResponse(`{"auditLogEvents": []}`),
+			scope: changesTeams,
+		},
+		{
+			name: "unreadable state",
+			resource: func(t *testing.T, c *client.Client) *v2.Resource {
+				return &v2.Resource{
+					Id:          &v2.ResourceId{ResourceType: teamResourceType.Id, Resource: "devs"},
+					Annotations: annotations.New(&v2.ETag{Value: "W/\"abc\""}),
+				}
+			},
+			audit: jsonResponse(`{"auditLogEvents": []}`),
+			scope: changesTeams,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(map[string]http.HandlerFunc{auditLogsRoute: tt.audit})
+			c := api.client(t)
+
+			resource := recordedResource(t, c, start, recorded)
+			if tt.resource != nil {
+				resource = tt.resource(t, c)
+			}
+
+			elapsed := tt.elapsed
+			if elapsed == 0 {
+				elapsed = time.Hour
+			}
+			s := newIncrementalSync(c, "acme", !tt.disabled)
+			s.now = func() time.Time { return start.Add(elapsed) }
+			s.reset()
+
+			var team client.Team
+			got := s.reuse(context.Background(), resource, tt.scope, &team)
+			if got != tt.want {
+				t.Errorf("reuse = %v, want %v", got, tt.want)
+			}
+			if got && (team.Name != "devs" || len(team.Members) != 1 || team.Members[0].GithubLogin != "alice") {
+				t.Errorf("reused team = %+v, want the recorded one", team)
+			}
+			if n := api.called(auditLogsRoute); n != tt.wantAudit {
+				t.Errorf("audit log read %d times, want %d", n, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestIncrementalSyncReadsAuditLogOncePerSync(t *testing.T) {
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		auditLogsRoute: jsonResponse(`{"auditLogEvents": []}`),
+	})
+	c := api.client(t)
+	resource := recordedResource(t, c, start, &client.Team{Name: "devs"})
+
+	s := newIncrementalSync(c, "acme", true)
+	s.now = func() time.Time { return start.Add(time.Hour) }
+	s.reset()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		var team client.Team
+		if !s.reuse(ctx, resource, changesTeams, &team) {
+			t.Fatalf("reuse %d failed", i)
+		}
+	}
+	if n := api.called(auditLogsRoute); n != 1 {
+		t.Errorf("audit log read %d times in one sync, want 1", n)
+	}
+
+	s.reset()
+	var team client.Team
+	if !s.reuse(ctx, resource, changesTeams, &team) {
+		t.Fatal("reuse failed in the next sync")
+	}
+	if n := api.called(auditLogsRoute); n != 2 {
+		t.Errorf("audit log read %d times over two syncs, want 2", n)
+	}
+}
+
+func TestTeamGrantsReuseRecordedMembers(t *testing.T) {
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		auditLogsRoute:            jsonResponse(`{"auditLogEvents": []}`),
+		membersRoute:              jsonResponse(`{"members": [{"role": "member", "user": {"githubLogin": "alice", "email": "alice@example.com"}}]}`),
+		"GET /api/orgs/acme/saml": statusResponse(http.StatusNotFound),
+	})
+	c := api.client(t)
+	resource := recordedResource(t, c, start, &client.Team{
+		Kind:    teamKindPulumi,
+		Name:    "devs",
+		Members: []client.UserInfo{{GithubLogin: "alice"}},
+	})
+
+	incremental := newIncrementalSync(c, "acme", true)
+	incremental.now = func() time.Time { return start.Add(time.Hour) }
+	incremental.reset()
+	members := newMemberIndex(c, "acme", PrincipalKeyEmail)
+	builder := newTeamBuilder(c, "acme", members, newSCIMGuard(c, "acme", false), c.NewTeamPrefetcher("acme", 0), incremental, newSAMLIndex(c, "acme"))
+
+	grants, _, annos, err := builder.Grants(context.Background(), resource, nil)
+	if err != nil {
+		t.Fatalf("Grants failed: %v", err)
+	}
+
+	if n := api.called("GET /api/orgs/acme/teams"); n != 0 {
+		t.Errorf("teams listed %d times, want the recorded team to be used", n)
+	}
+	if len(grants) != 1 || grants[0].Principal.Id.Resource != "alice@example.com" {
+		t.Errorf("grants = %v, want one for alice@example.com", grants)
+	}
+
+	// The state is recorded again with the new cursor, so the next sync can reuse it too
+	etag := &v2.ETag{}
+	if ok, err := annos.Pick(etag); err != nil || !ok {
+		t.Fatalf("grants carry no incremental state: %v", err)
+	}
+	next := newIncrementalSync(c, "acme", true)
+	next.now = func() time.Time { return start.Add(2 * time.Hour) }
+	next.reset()
+	var team client.Team
+	if !next.reuse(context.Background(), &v2.Resource{Id: resource.Id, Annotations: annos}, changesTeams, &team) {
+		t.Fatal("recorded state was not reusable by the next sync")
+	}
+	if team.Name != "devs" {
+		t.Errorf("recorded team = %+v, want devs", team)
+	}
+}
@@ -0,0 +1,246 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+)
+
+const (
+	// PrincipalKeyLogin identifies user resources by their Pulumi login
+	PrincipalKeyLogin = "login"
+	// PrincipalKeyEmail identifies user resources by their email address
+	PrincipalKeyEmail = "email"
+)
+
+// identityProviders maps the identities reported by Pulumi to display names
+var identityProviders = map[string]string{
+	"github":    "GitHub",
+	"gitlab":    "GitLab",
+	"bitbucket": "Bitbucket",
+	"saml":      "SAML",
+	"email":     "Email",
+}
+
+// userPrincipalID returns the resource ID of a user for the given principal key.
+// Users whose email isn't visible to the token fall back to their login.
+func userPrincipalID(user client.UserInfo, principalKey string) string {
+	if principalKey == PrincipalKeyEmail && user.Email != "" {
+		return strings.ToLower(user.Email)
+	}
+	return user.GithubLogin
+}
+
+// identityProvider returns the display name of the provider the user signs in with
+func identityProvider(user client.UserInfo) string {
+	if len(user.Identities) == 0 {
+		return ""
+	}
+	if name, ok := identityProviders[strings.ToLower(user.Identities[0])]; ok {
+		return name
+	}
+	return user.Identities[0]
+}
+
+// memberIndex maps Pulumi logins to user resource IDs and back. It is loaded lazily from
+// the organization member list and reloaded once when a lookup misses. Keys still missing after
+// that reload are remembered until the next load, so departed users named by old stack updates or
+// tokens don't reload the member list on every lookup. Each sync starts over with reset.
+type memberIndex struct {
+	client       *client.Client
+	orgName      string
+	principalKey string
+
+	mu      sync.Mutex
+	loaded  bool
+	byLogin map[string]client.User
+	byEmail map[string]client.User
+	missing map[memberKey]struct{}
+}
+
+// memberKey is a lookup key, which is a login or an email address
+type memberKey struct {
+	key     string
+	byEmail bool
+}
+
+func newMemberIndex(client *client.Client, orgName, principalKey string) *memberIndex {
+	return &memberIndex{
+		client:       client,
+		orgName:      orgName,
+		principalKey: principalKey,
+	}
+}
+
+// principalID returns the resource ID for a user, looking up their email if needed
+func (m *memberIndex) principalID(ctx context.Context, user client.UserInfo) (string, error) {
+	if m.principalKey != PrincipalKeyEmail || user.Email != "" {
+		return userPrincipalID(user, m.principalKey), nil
+	}
+
+	member, err := m.find(ctx, user.GithubLogin, false)
+	if err != nil {
+		return "", err
+	}
+	if member == nil {
+		return user.GithubLogin, nil
+	}
+	return userPrincipalID(member.User, m.principalKey), nil
+}
+
+// loginPrincipalID returns the resource ID for the user with the given login, or an empty ID
+// when there is no login
+func (m *memberIndex) loginPrincipalID(ctx context.Context, login string) (string, error) {
+	if login == "" {
+		return "", nil
+	}
+	return m.principalID(ctx, client.UserInfo{GithubLogin: login})
+}
+
+// login returns the Pulumi login of the user with the given resource ID
+func (m *memberIndex) login(ctx context.Context, principalID string) (string, error) {
+	if m.principalKey != PrincipalKeyEmail || !strings.Contains(principalID, "@") {
+		return principalID, nil
+	}
+
+	member, err := m.find(ctx, strings.ToLower(principalID), true)
+	if err != nil {
+		return "", err
+	}
+	if member == nil {
+		return "", fmt.Errorf("no member of organization %s has email %s", m.orgName, principalID)
+	}
+	return member.User.GithubLogin, nil
+}
+
+func (m *memberIndex) find(ctx context.Context, key string, byEmail bool) (*client.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fresh := false
+	if !m.loaded {
+		if err := m.load(ctx); err != nil {
+			return nil, err
+		}
+		fresh = true
+	}
+
+	member, ok := m.lookup(key, byEmail)
+	if ok {
+		return member, nil
+	}
+	if _, known := m.missing[memberKey{key, byEmail}]; known {
+		return nil, nil
+	}
+
+	if !fresh {
+		if err := m.load(ctx); err != nil {
+			return nil, err
+		}
+		member, ok = m.lookup(key, byEmail)
+	}
+	if !ok {
+		m.missing[memberKey{key, byEmail}] = struct{}{}
+	}
+	return member, nil
+}
+
+func (m *memberIndex) lookup(key string, byEmail bool) (*client.User, bool) {
+	idx := m.byLogin
+	if byEmail {
+		idx = m.byEmail
+	}
+
+	member, ok := idx[key]
+	if !ok {
+		return nil, false
+	}
+	return &member, true
+}
+
+// current looks up the member with the given resource ID in the member list as Pulumi reports it
+// now, past every cache and without touching the index, for actions that must not act on what an
+// earlier sync saw. It returns nil if no member has the ID.
+func (m *memberIndex) current(ctx context.Context, principalID string) (*client.User, error) {
+	byLogin, byEmail, err := m.list(client.Uncached(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	idx, key := byLogin, principalID
+	if m.principalKey == PrincipalKeyEmail && strings.Contains(principalID, "@") {
+		idx, key = byEmail, strings.ToLower(principalID)
+	}
+
+	member, ok := idx[key]
+	if !ok {
+		return nil, nil
+	}
+	return &member, nil
+}
+
+func (m *memberIndex) load(ctx context.Context) error {
+	byLogin, byEmail, err := m.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Keys that now resolve, such as invitees who have since joined, are no longer missing
+	missing := make(map[memberKey]struct{}, len(m.missing))
+	for key := range m.missing {
+		idx := byLogin
+		if key.byEmail {
+			idx = byEmail
+		}
+		if _, ok := idx[key.key]; !ok {
+			missing[key] = struct{}{}
+		}
+	}
+
+	m.byLogin = byLogin
+	m.byEmail = byEmail
+	m.missing = missing
+	m.loaded = true
+	return nil
+}
+
+// list returns the organization's members by login and by lowercase email
+func (m *memberIndex) list(ctx context.Context) (map[string]client.User, map[string]client.User, error) {
+	byLogin := make(map[string]client.User)
+	byEmail := make(map[string]client.User)
+
+	var token string
+	for {
+		resp, err := m.client.ListUsers(ctx, m.orgName, token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list org members: %w", err)
+		}
+
+		for _, member := range resp.Members {
+			byLogin[member.User.GithubLogin] = member
+			if member.User.Email != "" {
+				byEmail[strings.ToLower(member.User.Email)] = member
+			}
+		}
+
+		if resp.ContinuationToken == "" {
+			return byLogin, byEmail, nil
+		}
+		token = resp.ContinuationToken
+	}
+}
+
+// reset drops the member list and the keys known to be missing, so that the next lookup lists
+// the members again
+func (m *memberIndex) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.loaded = false
+	m.byLogin = nil
+	m.byEmail = nil
+	m.missing = nil
+}
@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+)
+
+const membersRoute = "GET /api/orgs/acme/members"
+
+func TestMemberIndexRemembersMissingLogins(t *testing.T) {
+	api := newFakeAPI(nil)
+	api.set(membersRoute, jsonResponse(`{"members": [{"role": "member", "user": {"githubLogin": "alice", "email": "Alice@example.com"}}]}`))
+	c := api.client(t)
+	members := newMemberIndex(c, "acme", PrincipalKeyEmail)
+	ctx := context.Background()
+
+	id, err := members.principalID(ctx, client.UserInfo{GithubLogin: "alice"})
+	if err != nil {
+		t.Fatalf("principalID failed: %v", err)
+	}
+	if id != "alice@example.com" {
+		t.Errorf("principalID(alice) = %q, want alice@example.com", id)
+	}
+
+	// A sync boundary makes the cached member list stale, so every reload reaches the API
+	for i := 0; i < 3; i++ {
+		c.FinishSync()
+		id, err := members.principalID(ctx, client.UserInfo{GithubLogin: "departed"})
+		if err != nil {
+			t.Fatalf("principalID failed: %v", err)
+		}
+		if id != "departed" {
+			t.Errorf("principalID(departed) = %q, want the login", id)
+		}
+	}
+
+	// One load, then one reload for the first miss only
+	if n := api.called(membersRoute); n != 2 {
+		t.Errorf("member list fetched %d times, want 2", n)
+	}
+}
+
+func TestMemberIndexReloadsForNewMembers(t *testing.T) {
+	api := newFakeAPI(nil)
+	api.set(membersRoute, jsonResponse(`{"members": [{"role": "member", "user": {"githubLogin": "alice", "email": "alice@example.com"}}]}`))
+	c := api.client(t)
+	members := newMemberIndex(c, "acme", PrincipalKeyEmail)
+	ctx := context.Background()
+
+	if _, err := members.login(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	api.set(membersRoute, jsonResponse(`{"members": [
+		{"role": "member", "user": {"githubLogin": "alice", "email": "alice@example.com"}},
+		{"role": "member", "user": {"githubLogin": "bob", "email": "bob@example.com"}}
+	]}`))
+	c.FinishSync()
+
+	login, err := members.login(ctx, "Bob@example.com")
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if login != "bob" {
+		t.Errorf("login(bob@example.com) = %q, want bob", login)
+	}
+
+	if _, err := members.login(ctx, "carol@example.com"); err == nil {
+		t.Error("login(carol@example.com) succeeded for a non-member")
+	}
+}
+
+func TestMemberIndexForgetsMissingLoginsAfterReset(t *testing.T) {
+	api := newFakeAPI(nil)
+	api.set(membersRoute, jsonResponse(`{"members": [{"role": "member", "user": {"githubLogin": "alice", "email": "alice@example.com"}}]}`))
+	c := api.client(t)
+	members := newMemberIndex(c, "acme", PrincipalKeyEmail)
+	ctx := context.Background()
+
+	id, err := members.loginPrincipalID(ctx, "invitee")
+	if err != nil {
+		t.Fatalf("loginPrincipalID failed: %v", err)
+	}
+	if id != "invitee" {
+		t.Errorf("loginPrincipalID(invitee) = %q, want the login before they join", id)
+	}
+
+	// The invitee accepts, and the next sync sees their email
+	api.set(membersRoute, jsonResponse(`{"members": [
+		{"role": "member", "user": {"githubLogin": "alice", "email": "alice@example.com"}},
+		{"role": "member", "user": {"githubLogin": "invitee", "email": "invitee@example.com"}}
+	]}`))
+	c.FinishSync()
+	members.reset()
+
+	id, err = members.loginPrincipalID(ctx, "invitee")
+	if err != nil {
+		t.Fatalf("loginPrincipalID failed: %v", err)
+	}
+	if id != "invitee@example.com" {
+		t.Errorf("loginPrincipalID(invitee) = %q, want their email once they joined", id)
+	}
+}
@@ -0,0 +1,210 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	batonGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const (
+	policyDecisionAllow     = "allow"
+	policyTokenTypeOrg      = "organization"
+	policyTokenTypeTeam     = "team"
+	policyTokenTypePersonal = "personal"
+)
+
+type oidcIssuerBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+}
+
+var _ connectorbuilder.ResourceSyncer = &oidcIssuerBuilder{}
+
+func oidcIssuerResource(issuer client.OIDCIssuer, policy *client.AuthPolicy, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"id":          issuer.ID,
+		"name":        issuer.Name,
+		"url":         issuer.URL,
+		"issuer":      issuer.Issuer,
+		"thumbprints": strings.Join(issuer.Thumbprints, ", "),
+	}
+	if issuer.MaxExpiration > 0 {
+		profile["max_expiration_seconds"] = issuer.MaxExpiration
+	}
+	if policy != nil {
+		rules := make([]string, 0, len(policy.Policies))
+		for _, rule := range policy.Policies {
+			rules = append(rules, describePolicyRule(rule))
+		}
+		profile["policy_rules"] = strings.Join(rules, "; ")
+	}
+
+	return batonResource.NewAppResource(
+		issuer.Name,
+		oidcIssuerResourceType,
+		issuer.ID,
+		[]batonResource.AppTraitOption{
+			batonResource.WithAppProfile(profile),
+			batonResource.WithAppHelpURL(issuer.URL),
+		},
+		batonResource.WithParentResourceID(parentResourceId),
+	)
+}
+
+// describePolicyRule renders a policy rule as e.g. "allow team token for platform when sub=repo:acme/*"
+func describePolicyRule(rule client.AuthPolicyRule) string {
+	var target string
+	switch rule.TokenType {
+	case policyTokenTypeTeam:
+		target = fmt.Sprintf("team token for %s", rule.TeamName)
+	case policyTokenTypePersonal:
+		target = fmt.Sprintf("personal token for %s", rule.UserLogin)
+	default:
+		target = fmt.Sprintf("%s token", rule.TokenType)
+	}
+	if len(rule.AuthorizedPermissions) > 0 {
+		target += fmt.Sprintf(" (%s)", strings.Join(rule.AuthorizedPermissions, ", "))
+	}
+
+	return fmt.Sprintf("%s %s when %s", rule.Decision, target, describeClaimRules(rule.Rules))
+}
+
+// describeClaimRules renders claim rules in a stable order
+func describeClaimRules(rules map[string]string) string {
+	if len(rules) == 0 {
+		return "any claims"
+	}
+
+	claims := make([]string, 0, len(rules))
+	for claim, value := range rules {
+		claims = append(claims, fmt.Sprintf("%s=%s", claim, value))
+	}
+	sort.Strings(claims)
+	return strings.Join(claims, ", ")
+}
+
+func (o *oidcIssuerBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return oidcIssuerResourceType
+}
+
+// List returns the OIDC issuers registered with the organization.
+func (o *oidcIssuerBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	orgParentID := &v2.ResourceId{
+		ResourceType: orgResourceType.Id,
+		Resource:     o.orgName,
+	}
+
+	issuers, err := o.client.ListOIDCIssuers(ctx, o.orgName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to list OIDC issuers: %w", err)
+	}
+
+	resources := make([]*v2.Resource, 0, len(issuers))
+	for _, issuer := range issuers {
+		policy, err := o.client.GetOIDCIssuerPolicy(ctx, o.orgName, issuer.ID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		resource, err := oidcIssuerResource(issuer, policy, orgParentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// Entitlements returns an empty list since issuers are principals rather than grantable resources
+func (o *oidcIssuerBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns the team and organization entitlements the issuer's policy hands out to federated identities.
+func (o *oidcIssuerBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	policy, err := o.client.GetOIDCIssuerPolicy(ctx, o.orgName, resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	orgRes := &v2.Resource{Id: &v2.ResourceId{ResourceType: orgResourceType.Id, Resource: o.orgName}}
+
+	// Several rules can grant the same entitlement, so collect their claims per entitlement
+	type target struct {
+		resource *v2.Resource
+		slug     string
+	}
+	var targets []target
+	claims := make(map[string][]string)
+	for _, rule := range policy.Policies {
+		if rule.Decision != policyDecisionAllow {
+			continue
+		}
+
+		var t target
+		switch rule.TokenType {
+		case policyTokenTypeTeam:
+			// A team rule without a team grants nothing
+			if rule.TeamName == "" {
+				continue
+			}
+			t = target{
+				resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: teamResourceType.Id, Resource: rule.TeamName}},
+				slug:     entitlementSlugMember,
+			}
+		case policyTokenTypeOrg:
+			t = target{resource: orgRes, slug: entitlementSlugMember}
+			for _, permission := range rule.AuthorizedPermissions {
+				if permission == roleAdmin {
+					t.slug = entitlementSlugAdmin
+				}
+			}
+		default:
+			// Personal tokens act as their user and don't map to an entitlement
+			continue
+		}
+
+		key := formatResourceID(t.resource.Id.ResourceType, t.resource.Id.Resource, t.slug)
+		if _, ok := claims[key]; !ok {
+			targets = append(targets, t)
+		}
+		claims[key] = append(claims[key], describeClaimRules(rule.Rules))
+	}
+
+	rv := make([]*v2.Grant, 0, len(targets))
+	for _, t := range targets {
+		key := formatResourceID(t.resource.Id.ResourceType, t.resource.Id.Resource, t.slug)
+		rv = append(rv, batonGrant.NewGrant(
+			t.resource,
+			t.slug,
+			resource.Id,
+			batonGrant.WithGrantMetadata(map[string]interface{}{
+				"source":      "oidc_issuer",
+				"claim_rules": strings.Join(claims[key], "; "),
+			}),
+			// Federated access is changed by editing the issuer policy, not through membership APIs
+			batonGrant.WithAnnotation(&v2.GrantImmutable{SourceId: resource.Id.Resource}),
+		))
+	}
+
+	return rv, "", nil, nil
+}
+
+func newOIDCIssuerBuilder(client *client.Client, orgName string) *oidcIssuerBuilder {
+	return &oidcIssuerBuilder{
+		resourceType: oidcIssuerResourceType,
+		client:       client,
+		orgName:      orgName,
+	}
+}
@@ -16,16 +16,23 @@ import (
 )
 
 const (
-	entitlementSlugAdmin  = "admin"
-	entitlementSlugMember = "member"
-	roleAdmin             = "admin"
-	roleMember            = "member"
+	entitlementSlugAdmin        = "admin"
+	entitlementSlugMember       = "member"
+	entitlementSlugVirtualAdmin = "virtual_admin"
+	roleAdmin                   = "admin"
+	roleMember                  = "member"
+
+	// adminSourceRole and adminSourceVirtual record where an admin right comes from
+	adminSourceRole    = "org_role"
+	adminSourceVirtual = "virtual_admin"
 )
 
 type orgBuilder struct {
 	resourceType *v2.ResourceType
 	client       *client.Client
 	orgName      string
+	members      *memberIndex
+	scim         *scimGuard
 }
 
 var _ connectorbuilder.ResourceSyncer = &orgBuilder{}
@@ -36,6 +43,10 @@ func orgResource(orgName string) (*v2.Resource, error) {
 		orgName,
 		orgResourceType,
 		orgName,
+		batonResource.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: projectResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: accessTokenResourceType.Id},
+		),
 	)
 }
 
@@ -60,10 +71,11 @@ func formatResourceID(parts ...string) string {
 
 // Entitlements returns the entitlements available for the organization.
 func (o *orgBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	// Besides users, OIDC issuers whose policy issues organization tokens hold membership or admin
 	memberEnt := batonEntitlement.NewAssignmentEntitlement(
 		resource,
 		entitlementSlugMember,
-		batonEntitlement.WithGrantableTo(userResourceType),
+		batonEntitlement.WithGrantableTo(userResourceType, oidcIssuerResourceType),
 		batonEntitlement.WithDescription("Member of the Pulumi organization"),
 		batonEntitlement.WithDisplayName("Member"),
 	)
@@ -71,12 +83,21 @@ func (o *orgBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *p
 	adminEnt := batonEntitlement.NewPermissionEntitlement(
 		resource,
 		entitlementSlugAdmin,
-		batonEntitlement.WithGrantableTo(userResourceType),
+		batonEntitlement.WithGrantableTo(userResourceType, oidcIssuerResourceType),
 		batonEntitlement.WithDescription("Administrator of the Pulumi organization"),
 		batonEntitlement.WithDisplayName("Administrator"),
 	)
 
-	return []*v2.Entitlement{memberEnt, adminEnt}, "", nil, nil
+	virtualAdminEnt := batonEntitlement.NewPermissionEntitlement(
+		resource,
+		entitlementSlugVirtualAdmin,
+		batonEntitlement.WithGrantableTo(userResourceType),
+		batonEntitlement.WithDescription("Administrator of the Pulumi organization through SSO group mapping or enterprise policy"),
+		batonEntitlement.WithDisplayName("Virtual Administrator"),
+		batonEntitlement.WithAnnotation(&v2.EntitlementImmutable{}),
+	)
+
+	return []*v2.Entitlement{memberEnt, adminEnt, virtualAdminEnt}, "", nil, nil
 }
 
 // Grants returns the granted entitlements for users in the organization.
@@ -96,31 +117,70 @@ func (o *orgBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *
 	}
 
 	for _, member := range resp.Members {
-		// Create grant for the appropriate role (admin or member)
-		entSlug := entitlementSlugMember
-		if member.Role == roleAdmin {
-			entSlug = entitlementSlugAdmin
-		}
-
+		userID := userPrincipalID(member.User, o.members.principalKey)
 		principalId := &v2.ResourceId{
 			ResourceType: userResourceType.Id,
-			Resource:     member.User.GithubLogin,
+			Resource:     userID,
 		}
 
-		g := batonGrant.NewGrant(
-			resource,
-			entSlug,
-			principalId,
-		)
-		g.Id = formatResourceID("org", o.orgName, "grant", member.User.GithubLogin, entSlug)
-		g.Principal.DisplayName = member.User.Name
-
-		rv = append(rv, g)
+		scimManaged := o.scim.memberIsManaged(ctx, member)
+
+		for _, entSlug := range memberEntitlementSlugs(member) {
+			var opts []batonGrant.GrantOption
+			switch entSlug {
+			case entitlementSlugMember:
+				if scimManaged {
+					opts = append(opts, batonGrant.WithGrantMetadata(map[string]interface{}{
+						"managed_by": membershipSourceSCIM,
+					}))
+				}
+			case entitlementSlugAdmin:
+				opts = append(opts, batonGrant.WithGrantMetadata(map[string]interface{}{
+					"admin_source": adminSourceRole,
+				}))
+			case entitlementSlugVirtualAdmin:
+				// Virtual admin rights come from outside Pulumi and cannot be changed through the role API
+				opts = append(opts,
+					batonGrant.WithGrantMetadata(map[string]interface{}{
+						"admin_source": adminSourceVirtual,
+						"role":         member.Role,
+					}),
+					batonGrant.WithAnnotation(&v2.GrantImmutable{}),
+				)
+			}
+
+			g := batonGrant.NewGrant(
+				resource,
+				entSlug,
+				principalId,
+				opts...,
+			)
+			g.Id = formatResourceID("org", o.orgName, "grant", userID, entSlug)
+			g.Principal.DisplayName = member.User.Name
+
+			rv = append(rv, g)
+		}
 	}
 
 	return rv, resp.ContinuationToken, annotations, nil
 }
 
+// memberEntitlementSlugs returns the org entitlements held by a member. Virtual admin rights are
+// reported separately from the admin role, since they are managed elsewhere, and a member can
+// hold both.
+func memberEntitlementSlugs(member client.User) []string {
+	switch {
+	case member.VirtualAdmin && member.Role == roleAdmin:
+		return []string{entitlementSlugAdmin, entitlementSlugVirtualAdmin}
+	case member.VirtualAdmin:
+		return []string{entitlementSlugMember, entitlementSlugVirtualAdmin}
+	case member.Role == roleAdmin:
+		return []string{entitlementSlugAdmin}
+	default:
+		return []string{entitlementSlugMember}
+	}
+}
+
 // Grant implements the entitlement grant operation
 func (o *orgBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
 	if principal == nil || principal.Id == nil {
@@ -138,6 +198,7 @@ func (o *orgBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlem
 	// Match against the known entitlement IDs we create
 	adminEntID := formatResourceID(orgResourceType.Id, o.orgName, entitlementSlugAdmin)
 	memberEntID := formatResourceID(orgResourceType.Id, o.orgName, entitlementSlugMember)
+	virtualAdminEntID := formatResourceID(orgResourceType.Id, o.orgName, entitlementSlugVirtualAdmin)
 
 	var role string
 	switch entitlement.Id {
@@ -145,12 +206,19 @@ func (o *orgBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlem
 		role = roleAdmin
 	case memberEntID:
 		role = roleMember
+	case virtualAdminEntID:
+		return nil, nil, fmt.Errorf("virtual admin rights are managed by SSO group mapping or enterprise policy and cannot be granted")
 	default:
 		return nil, nil, fmt.Errorf("unknown entitlement ID: %s", entitlement.Id)
 	}
 
+	username, err := o.members.login(ctx, principal.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Update the user's role in the organization
-	return nil, nil, o.client.UpdateUserRole(ctx, o.orgName, principal.Id.Resource, role)
+	return nil, nil, o.client.UpdateUserRole(ctx, o.orgName, username, role)
 }
 
 // Revoke implements the entitlement revoke operation
@@ -170,28 +238,48 @@ func (o *orgBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.A
 		return nil, fmt.Errorf("cannot revoke org role from non-user resource type: %s", grant.Principal.Id.ResourceType)
 	}
 
-	username := grant.Principal.Id.Resource
+	userID := grant.Principal.Id.Resource
+	username, err := o.members.login(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Match against the known grant ID patterns we create
-	adminGrantID := formatResourceID("org", o.orgName, "grant", username, entitlementSlugAdmin)
-	memberGrantID := formatResourceID("org", o.orgName, "grant", username, entitlementSlugMember)
+	adminGrantID := formatResourceID("org", o.orgName, "grant", userID, entitlementSlugAdmin)
+	memberGrantID := formatResourceID("org", o.orgName, "grant", userID, entitlementSlugMember)
+	virtualAdminGrantID := formatResourceID("org", o.orgName, "grant", userID, entitlementSlugVirtualAdmin)
 
 	switch grant.Id {
 	case adminGrantID:
 		// When admin is revoked, downgrade to member
 		return nil, o.client.UpdateUserRole(ctx, o.orgName, username, roleMember)
 	case memberGrantID:
+		member, err := o.members.find(ctx, username, false)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			scimManaged := o.scim.memberIsManaged(ctx, *member)
+			if err := o.scim.checkRevoke(ctx, scimManaged, fmt.Sprintf("membership of %s in organization %s", username, o.orgName)); err != nil {
+				return nil, err
+			}
+		}
+
 		// When member is revoked, remove from org
 		return nil, o.client.RemoveUser(ctx, o.orgName, username)
+	case virtualAdminGrantID:
+		return nil, fmt.Errorf("virtual admin rights are managed by SSO group mapping or enterprise policy and cannot be revoked")
 	default:
 		return nil, fmt.Errorf("unknown grant ID: %s", grant.Id)
 	}
 }
 
-func newOrgBuilder(client *client.Client, orgName string) *orgBuilder {
+func newOrgBuilder(client *client.Client, orgName string, members *memberIndex, scim *scimGuard) *orgBuilder {
 	return &orgBuilder{
 		resourceType: orgResourceType,
 		client:       client,
 		orgName:      orgName,
+		members:      members,
+		scim:         scim,
 	}
 }
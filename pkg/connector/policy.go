@@ -0,0 +1,344 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	batonEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	batonGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const entitlementSlugEnforced = "enforced"
+
+type policyPackBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+
+	appliedMu sync.Mutex
+	applied   map[string][]policyPackApplication
+}
+
+var _ connectorbuilder.ResourceSyncer = &policyPackBuilder{}
+
+// policyPackApplication records a policy group enforcing a policy pack
+type policyPackApplication struct {
+	group string
+	pack  client.AppliedPolicyPack
+}
+
+func policyPackResource(pack client.PolicyPack, applications []policyPackApplication, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":         pack.Name,
+		"display_name": pack.DisplayName,
+		"versions":     strings.Join(pack.VersionTags, ", "),
+	}
+	if len(pack.VersionTags) > 0 {
+		profile["latest_version"] = pack.VersionTags[len(pack.VersionTags)-1]
+	}
+
+	levels := make(map[string]struct{})
+	for _, application := range applications {
+		levels[application.pack.EnforcementLevel()] = struct{}{}
+	}
+	enforcementLevels := make([]string, 0, len(levels))
+	for level := range levels {
+		enforcementLevels = append(enforcementLevels, level)
+	}
+	sort.Strings(enforcementLevels)
+	profile["enforcement_levels"] = strings.Join(enforcementLevels, ", ")
+
+	displayName := pack.DisplayName
+	if displayName == "" {
+		displayName = pack.Name
+	}
+
+	return batonResource.NewAppResource(
+		displayName,
+		policyPackResourceType,
+		pack.Name,
+		[]batonResource.AppTraitOption{
+			batonResource.WithAppProfile(profile),
+		},
+		batonResource.WithParentResourceID(parentResourceId),
+	)
+}
+
+// policyPackApplications returns the policy groups enforcing each policy pack, keyed by pack name.
+// The result is reloaded when packs are listed so that each sync sees the current groups.
+func (o *policyPackBuilder) policyPackApplications(ctx context.Context, reload bool) (map[string][]policyPackApplication, error) {
+	o.appliedMu.Lock()
+	defer o.appliedMu.Unlock()
+
+	if o.applied != nil && !reload {
+		return o.applied, nil
+	}
+
+	groups, err := o.client.ListPolicyGroups(ctx, o.orgName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy groups: %w", err)
+	}
+
+	applied := make(map[string][]policyPackApplication)
+	for _, summary := range groups {
+		group, err := o.client.GetPolicyGroup(ctx, o.orgName, summary.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, pack := range group.AppliedPolicyPacks {
+			applied[pack.Name] = append(applied[pack.Name], policyPackApplication{group: group.Name, pack: pack})
+		}
+	}
+	o.applied = applied
+
+	return applied, nil
+}
+
+func (o *policyPackBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return policyPackResourceType
+}
+
+// List returns the policy packs published to the organization.
+func (o *policyPackBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	orgParentID := &v2.ResourceId{
+		ResourceType: orgResourceType.Id,
+		Resource:     o.orgName,
+	}
+
+	packs, err := o.client.ListPolicyPacks(ctx, o.orgName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to list policy packs: %w", err)
+	}
+
+	applied, err := o.policyPackApplications(ctx, true)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	resources := make([]*v2.Resource, 0, len(packs))
+	for _, pack := range packs {
+		resource, err := policyPackResource(pack, applied[pack.Name], orgParentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// Entitlements returns the enforcement entitlement held by policy groups applying the pack.
+func (o *policyPackBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	enforcedEnt := batonEntitlement.NewPermissionEntitlement(
+		resource,
+		entitlementSlugEnforced,
+		batonEntitlement.WithGrantableTo(policyGroupResourceType),
+		batonEntitlement.WithDescription("Policy group enforcing the policy pack"),
+		batonEntitlement.WithDisplayName("Enforced"),
+	)
+
+	return []*v2.Entitlement{enforcedEnt}, "", nil, nil
+}
+
+// Grants returns the policy groups enforcing the pack, with the version and enforcement level applied.
+func (o *policyPackBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	applied, err := o.policyPackApplications(ctx, false)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var rv []*v2.Grant
+	for _, application := range applied[resource.Id.Resource] {
+		rv = append(rv, batonGrant.NewGrant(
+			resource,
+			entitlementSlugEnforced,
+			&v2.ResourceId{
+				ResourceType: policyGroupResourceType.Id,
+				Resource:     application.group,
+			},
+			batonGrant.WithGrantMetadata(map[string]interface{}{
+				"version":           application.pack.VersionTag,
+				"enforcement_level": application.pack.EnforcementLevel(),
+			}),
+		))
+	}
+
+	return rv, "", nil, nil
+}
+
+func newPolicyPackBuilder(client *client.Client, orgName string) *policyPackBuilder {
+	return &policyPackBuilder{
+		resourceType: policyPackResourceType,
+		client:       client,
+		orgName:      orgName,
+	}
+}
+
+type policyGroupBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+	tagFilter    *stackTagFilter
+}
+
+var _ connectorbuilder.ResourceSyncer = &policyGroupBuilder{}
+var _ connectorbuilder.ResourceProvisionerV2 = &policyGroupBuilder{}
+
+func policyGroupResource(group client.PolicyGroupSummary, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":                      group.Name,
+		"is_org_default":            group.IsOrgDefault,
+		"stack_count":               group.NumStacks,
+		"enabled_policy_pack_count": group.NumEnabledPolicyPacks,
+	}
+
+	return batonResource.NewAppResource(
+		group.Name,
+		policyGroupResourceType,
+		group.Name,
+		[]batonResource.AppTraitOption{
+			batonResource.WithAppProfile(profile),
+		},
+		batonResource.WithParentResourceID(parentResourceId),
+	)
+}
+
+func (o *policyGroupBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return policyGroupResourceType
+}
+
+// List returns the policy groups of the organization.
+func (o *policyGroupBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	orgParentID := &v2.ResourceId{
+		ResourceType: orgResourceType.Id,
+		Resource:     o.orgName,
+	}
+
+	groups, err := o.client.ListPolicyGroups(ctx, o.orgName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to list policy groups: %w", err)
+	}
+
+	resources := make([]*v2.Resource, 0, len(groups))
+	for _, group := range groups {
+		resource, err := policyGroupResource(group, orgParentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// Entitlements returns the membership entitlement held by stacks in the group.
+func (o *policyGroupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	memberEnt := batonEntitlement.NewAssignmentEntitlement(
+		resource,
+		entitlementSlugMember,
+		batonEntitlement.WithGrantableTo(stackResourceType),
+		batonEntitlement.WithDescription("Stack enforced by the policy group"),
+		batonEntitlement.WithDisplayName("Member"),
+	)
+
+	return []*v2.Entitlement{memberEnt}, "", nil, nil
+}
+
+// Grants returns the stacks in the policy group.
+func (o *policyGroupBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	group, err := o.client.GetPolicyGroup(ctx, o.orgName, resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Grant, 0, len(group.Stacks))
+	for _, stack := range group.Stacks {
+		// Stacks left out by the tag filter aren't synced, so there is nothing to grant to
+		included, err := o.tagFilter.includesStack(ctx, o.client, o.orgName, stack.RoutingProject, stack.Name)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !included {
+			continue
+		}
+
+		rv = append(rv, batonGrant.NewGrant(
+			resource,
+			entitlementSlugMember,
+			&v2.ResourceId{
+				ResourceType: stackResourceType.Id,
+				Resource:     stackID(stack.RoutingProject, stack.Name),
+			},
+		))
+	}
+
+	return rv, "", nil, nil
+}
+
+// Grant adds a stack to the policy group
+func (o *policyGroupBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if principal == nil || principal.Id == nil {
+		return nil, nil, fmt.Errorf("principal is nil or has nil id")
+	}
+	if entitlement == nil || entitlement.Resource == nil || entitlement.Resource.Id == nil {
+		return nil, nil, fmt.Errorf("entitlement is nil or has nil resource")
+	}
+	if principal.Id.ResourceType != stackResourceType.Id {
+		return nil, nil, fmt.Errorf("cannot add non-stack resource type to a policy group: %s", principal.Id.ResourceType)
+	}
+
+	projectName, stackName, err := parseStackID(principal.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = o.client.AddStackToPolicyGroup(ctx, o.orgName, entitlement.Resource.Id.Resource, projectName, stackName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to add stack to policy group: %w", err)
+	}
+
+	return nil, nil, nil
+}
+
+// Revoke removes a stack from the policy group
+func (o *policyGroupBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if grant == nil || grant.Principal == nil || grant.Principal.Id == nil {
+		return nil, fmt.Errorf("grant is nil or has nil principal")
+	}
+	if grant.Entitlement == nil || grant.Entitlement.Resource == nil || grant.Entitlement.Resource.Id == nil {
+		return nil, fmt.Errorf("grant has nil entitlement or resource")
+	}
+	if grant.Principal.Id.ResourceType != stackResourceType.Id {
+		return nil, fmt.Errorf("cannot remove non-stack resource type from a policy group: %s", grant.Principal.Id.ResourceType)
+	}
+
+	projectName, stackName, err := parseStackID(grant.Principal.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.client.RemoveStackFromPolicyGroup(ctx, o.orgName, grant.Entitlement.Resource.Id.Resource, projectName, stackName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove stack from policy group: %w", err)
+	}
+
+	return nil, nil
+}
+
+func newPolicyGroupBuilder(client *client.Client, orgName string, tagFilter *stackTagFilter) *policyGroupBuilder {
+	return &policyGroupBuilder{
+		resourceType: policyGroupResourceType,
+		client:       client,
+		orgName:      orgName,
+		tagFilter:    tagFilter,
+	}
+}
@@ -0,0 +1,87 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+type projectBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+}
+
+var _ connectorbuilder.ResourceSyncer = &projectBuilder{}
+
+func projectResource(project client.Project, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":        project.Name,
+		"runtime":     project.Runtime,
+		"repo":        project.RepoName,
+		"stack_count": len(project.Stacks),
+	}
+
+	return batonResource.NewAppResource(
+		project.Name,
+		projectResourceType,
+		project.Name,
+		[]batonResource.AppTraitOption{
+			batonResource.WithAppProfile(profile),
+		},
+		batonResource.WithParentResourceID(parentResourceId),
+		batonResource.WithDescription(project.Description),
+		batonResource.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: stackResourceType.Id}),
+	)
+}
+
+func (o *projectBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return projectResourceType
+}
+
+// List returns the projects of the organization. Projects are only listed as children of the organization.
+func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil || parentResourceID.ResourceType != orgResourceType.Id {
+		return nil, "", nil, nil
+	}
+
+	projects, err := o.client.ListProjects(ctx, o.orgName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	resources := make([]*v2.Resource, 0, len(projects))
+	for _, project := range projects {
+		resource, err := projectResource(project, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, "", nil, nil
+}
+
+// Entitlements returns an empty list since project access is granted on stacks
+func (o *projectBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns an empty list since project access is granted on stacks
+func (o *projectBuilder) Grants(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func newProjectBuilder(client *client.Client, orgName string) *projectBuilder {
+	return &projectBuilder{
+		resourceType: projectResourceType,
+		client:       client,
+		orgName:      orgName,
+	}
+}
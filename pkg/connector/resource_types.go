@@ -23,6 +23,87 @@ var (
 		},
 	}
 
+	webhookResourceType = &v2.ResourceType{
+		Id:          "webhook",
+		DisplayName: "Webhook",
+		Description: "Pulumi organization or stack webhook",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_APP,
+		},
+	}
+
+	projectResourceType = &v2.ResourceType{
+		Id:          "project",
+		DisplayName: "Project",
+		Description: "Pulumi project",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_APP,
+		},
+	}
+
+	stackResourceType = &v2.ResourceType{
+		Id:          "stack",
+		DisplayName: "Stack",
+		Description: "Pulumi stack",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_APP,
+		},
+	}
+
+	agentPoolResourceType = &v2.ResourceType{
+		Id:          "agent_pool",
+		DisplayName: "Agent Pool",
+		Description: "Pulumi Deployments self-hosted agent pool",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_APP,
+		},
+	}
+
+	agentPoolTokenResourceType = &v2.ResourceType{
+		Id:          "agent_pool_token",
+		DisplayName: "Agent Pool Token",
+		Description: "Access token used by deployment agents to join a pool",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_SECRET,
+		},
+	}
+
+	accessTokenResourceType = &v2.ResourceType{
+		Id:          "access_token",
+		DisplayName: "Access Token",
+		Description: "Personal, organization or team access token for the Pulumi API",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_SECRET,
+		},
+	}
+
+	oidcIssuerResourceType = &v2.ResourceType{
+		Id:          "oidc_issuer",
+		DisplayName: "OIDC Issuer",
+		Description: "OIDC issuer trusted to exchange its tokens for Pulumi access tokens",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_APP,
+		},
+	}
+
+	policyPackResourceType = &v2.ResourceType{
+		Id:          "policy_pack",
+		DisplayName: "Policy Pack",
+		Description: "Pulumi CrossGuard policy pack",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_APP,
+		},
+	}
+
+	policyGroupResourceType = &v2.ResourceType{
+		Id:          "policy_group",
+		DisplayName: "Policy Group",
+		Description: "Pulumi CrossGuard policy group deciding which policy packs are enforced on which stacks",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_APP,
+		},
+	}
+
 	orgResourceType = &v2.ResourceType{
 		Id:          "organization",
 		DisplayName: "Organization",
@@ -0,0 +1,98 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	teamKindSCIM         = "scim"
+	membershipSourceSCIM = "scim"
+)
+
+// scimGuard tracks whether SCIM provisioning is enabled for the organization and keeps
+// revocations from fighting the identity provider, which would re-add the access.
+type scimGuard struct {
+	client        *client.Client
+	orgName       string
+	allowOverride bool
+
+	mu      sync.Mutex
+	enabled bool
+	loaded  bool
+}
+
+func newSCIMGuard(client *client.Client, orgName string, allowOverride bool) *scimGuard {
+	return &scimGuard{
+		client:        client,
+		orgName:       orgName,
+		allowOverride: allowOverride,
+	}
+}
+
+// isEnabled reports whether SCIM is enabled, reading the org settings on first use or when reload is set.
+// When the settings cannot be read the status is unknown, and the SCIM flags Pulumi sets on members
+// and teams are trusted on their own, so a settings outage neither fails the sync nor lets a
+// revocation undo what the identity provider pushed.
+func (s *scimGuard) isEnabled(ctx context.Context, reload bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loaded && !reload {
+		return s.enabled
+	}
+
+	settings, err := s.client.GetOrganizationSettings(ctx, s.orgName)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("failed to read organization settings, SCIM status is unknown", zap.Error(err))
+		s.enabled = true
+	} else {
+		s.enabled = settings.SCIMEnabled
+	}
+	s.loaded = true
+
+	return s.enabled
+}
+
+// reset drops the SCIM status, so that the next sync reads the organization settings again
+func (s *scimGuard) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.enabled = false
+	s.loaded = false
+}
+
+// memberIsManaged reports whether an org member was provisioned by SCIM
+func (s *scimGuard) memberIsManaged(ctx context.Context, member client.User) bool {
+	return member.ScimManaged && s.isEnabled(ctx, false)
+}
+
+// teamIsManaged reports whether a team's membership is pushed by SCIM
+func (s *scimGuard) teamIsManaged(ctx context.Context, team client.Team) bool {
+	return team.Kind == teamKindSCIM && s.isEnabled(ctx, false)
+}
+
+// checkRevoke refuses to revoke SCIM-managed access unless the override is set
+func (s *scimGuard) checkRevoke(ctx context.Context, managed bool, description string) error {
+	if !managed {
+		return nil
+	}
+
+	if s.allowOverride {
+		ctxzap.Extract(ctx).Warn("revoking SCIM-managed access, the identity provider may restore it",
+			zap.String("access", description),
+		)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%s is managed by SCIM and will be restored by the identity provider; revoke it there, or set --scim-override to revoke it in Pulumi anyway",
+		description,
+	)
+}
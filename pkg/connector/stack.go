@@ -0,0 +1,468 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	batonEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	batonGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const (
+	entitlementSlugRead  = "read"
+	entitlementSlugWrite = "write"
+)
+
+// stackPermissionSlugs maps Pulumi stack permission levels to stack entitlement slugs
+var stackPermissionSlugs = map[int]string{
+	client.StackPermissionRead:  entitlementSlugRead,
+	client.StackPermissionWrite: entitlementSlugWrite,
+	client.StackPermissionAdmin: entitlementSlugAdmin,
+}
+
+type stackBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+	tagFilter    *stackTagFilter
+	members      *memberIndex
+	teams        *client.TeamPrefetcher
+	agentPools   *agentPoolIndex
+	incremental  *incrementalSync
+
+	teamPermsMu     sync.Mutex
+	teamPerms       map[string][]teamStackPermission
+	teamPermsLoaded bool
+}
+
+// teamStackPermission is a team's permission on a stack
+type teamStackPermission struct {
+	Team       string `json:"team"`
+	Permission int    `json:"permission"`
+}
+
+// stackAccess is what the grants of a stack are built from
+type stackAccess struct {
+	Collaborators   []client.StackCollaborator `json:"collaborators"`
+	TeamPermissions []teamStackPermission      `json:"teamPermissions"`
+}
+
+var _ connectorbuilder.ResourceSyncer = &stackBuilder{}
+var _ connectorbuilder.ResourceProvisionerV2 = &stackBuilder{}
+
+// stackID returns the resource ID of a stack, which is unique within the organization
+func stackID(projectName, stackName string) string {
+	return projectName + "/" + stackName
+}
+
+// parseStackID splits a stack resource ID into its project and stack names
+func parseStackID(id string) (string, string, error) {
+	projectName, stackName, ok := strings.Cut(id, "/")
+	if !ok || projectName == "" || stackName == "" {
+		return "", "", fmt.Errorf("invalid stack resource id: %s", id)
+	}
+	return projectName, stackName, nil
+}
+
+func stackResource(stack client.Stack, settings *client.DeploymentSettings, pools map[string]client.AgentPool, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"project":        stack.ProjectName,
+		"stack":          stack.StackName,
+		"resource_count": stack.ResourceCount,
+	}
+	if lastUpdate, ok := unixTimestamp(stack.LastUpdate); ok {
+		profile["last_update"] = lastUpdate.Format(time.RFC3339)
+	}
+	if len(stack.Tags) > 0 {
+		tags := make([]string, 0, len(stack.Tags))
+		for key, value := range stack.Tags {
+			tags = append(tags, fmt.Sprintf("%s=%s", key, value))
+			profile["tag_"+key] = value
+		}
+		sort.Strings(tags)
+		profile["tags"] = strings.Join(tags, ", ")
+	}
+	for key, value := range deploymentProfile(settings, pools) {
+		profile[key] = value
+	}
+
+	return batonResource.NewAppResource(
+		stackID(stack.ProjectName, stack.StackName),
+		stackResourceType,
+		stackID(stack.ProjectName, stack.StackName),
+		[]batonResource.AppTraitOption{
+			batonResource.WithAppProfile(profile),
+		},
+		batonResource.WithParentResourceID(parentResourceId),
+	)
+}
+
+// deploymentProfile flattens a stack's deployment settings into profile fields
+func deploymentProfile(settings *client.DeploymentSettings, pools map[string]client.AgentPool) map[string]interface{} {
+	profile := map[string]interface{}{
+		"deployments_enabled": settings != nil,
+	}
+	if settings == nil {
+		return profile
+	}
+
+	if settings.SourceContext != nil && settings.SourceContext.Git != nil {
+		git := settings.SourceContext.Git
+		profile["deployment_source_repo"] = git.RepoURL
+		profile["deployment_source_branch"] = git.Branch
+		if git.RepoDir != "" {
+			profile["deployment_source_dir"] = git.RepoDir
+		}
+	}
+	if settings.GitHub != nil && settings.GitHub.Repository != "" {
+		profile["deployment_github_repository"] = settings.GitHub.Repository
+	}
+
+	if settings.AgentPoolID != "" {
+		profile["deployment_agent_pool_id"] = settings.AgentPoolID
+		if pool, ok := pools[settings.AgentPoolID]; ok {
+			profile["deployment_agent_pool"] = pool.Name
+		}
+	} else {
+		profile["deployment_agent_pool"] = "pulumi-hosted"
+	}
+
+	if settings.OperationContext != nil && settings.OperationContext.OIDC != nil {
+		oidc := settings.OperationContext.OIDC
+		var providers []string
+		if oidc.AWS != nil {
+			providers = append(providers, "aws")
+			profile["deployment_oidc_aws_role_arn"] = oidc.AWS.RoleARN
+		}
+		if oidc.Azure != nil {
+			providers = append(providers, "azure")
+			profile["deployment_oidc_azure_client_id"] = oidc.Azure.ClientID
+		}
+		if oidc.GCP != nil {
+			providers = append(providers, "gcp")
+			profile["deployment_oidc_gcp_service_account"] = oidc.GCP.ServiceAccount
+		}
+		profile["deployment_oidc_providers"] = strings.Join(providers, ", ")
+	}
+
+	return profile
+}
+
+func (o *stackBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return stackResourceType
+}
+
+// List returns the stacks of a project along with their deployment settings. Stacks are only
+// listed as children of their project.
+func (o *stackBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil || parentResourceID.ResourceType != projectResourceType.Id {
+		return nil, "", nil, nil
+	}
+
+	var token string
+	if pToken != nil {
+		token = pToken.Token
+	}
+
+	// Team permissions are loaded lazily by Grants, so a new listing only needs to drop the old ones
+	if token == "" {
+		o.teamPermsMu.Lock()
+		o.teamPermsLoaded = false
+		o.teamPermsMu.Unlock()
+	}
+
+	resp, err := o.client.ListProjectStacks(ctx, o.orgName, parentResourceID.Resource, token)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	pools, err := o.agentPools.get(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	resources := make([]*v2.Resource, 0, len(resp.Stacks))
+	for _, summary := range resp.Stacks {
+		// Tags are only returned for individual stacks. A stack deleted since the listing is not synced.
+		stack, err := o.client.GetStack(ctx, o.orgName, summary.ProjectName, summary.StackName)
+		if err != nil {
+			if client.IsNotFound(err) {
+				continue
+			}
+			return nil, "", nil, err
+		}
+		if !o.tagFilter.matches(stack.Tags) {
+			continue
+		}
+		stack.LastUpdate = summary.LastUpdate
+		stack.ResourceCount = summary.ResourceCount
+
+		settings, err := o.client.GetDeploymentSettings(ctx, o.orgName, stack.ProjectName, stack.StackName)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		resource, err := stackResource(*stack, settings, pools, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, resp.ContinuationToken, nil, nil
+}
+
+// teamPermissions returns the team permissions of every stack keyed by stack resource ID. Pulumi
+// only reports them per team, so the prefetched teams are indexed once and shared by all stacks.
+func (o *stackBuilder) teamPermissions(ctx context.Context) (map[string][]teamStackPermission, error) {
+	o.teamPermsMu.Lock()
+	defer o.teamPermsMu.Unlock()
+
+	if o.teamPermsLoaded {
+		return o.teamPerms, nil
+	}
+
+	teams, err := o.teams.ListTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	perms := make(map[string][]teamStackPermission)
+	for _, team := range teams {
+		for _, stack := range team.Stacks {
+			id := stackID(stack.ProjectName, stack.StackName)
+			perms[id] = append(perms[id], teamStackPermission{Team: team.Name, Permission: stack.Permission})
+		}
+	}
+
+	o.teamPerms = perms
+	o.teamPermsLoaded = true
+	return perms, nil
+}
+
+// Entitlements returns the read, write and admin permissions on a stack.
+func (o *stackBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	permissions := []struct {
+		slug        string
+		displayName string
+		description string
+	}{
+		{entitlementSlugRead, "Read", "Can view the stack and its updates"},
+		{entitlementSlugWrite, "Write", "Can update the stack"},
+		{entitlementSlugAdmin, "Admin", "Can update, delete and manage access to the stack"},
+	}
+
+	rv := make([]*v2.Entitlement, 0, len(permissions))
+	for _, permission := range permissions {
+		rv = append(rv, batonEntitlement.NewPermissionEntitlement(
+			resource,
+			permission.slug,
+			batonEntitlement.WithGrantableTo(userResourceType, teamResourceType),
+			batonEntitlement.WithDescription(permission.description),
+			batonEntitlement.WithDisplayName(permission.displayName),
+		))
+	}
+
+	return rv, "", nil, nil
+}
+
+// access returns the direct collaborators and team permissions on a stack
+func (o *stackBuilder) access(ctx context.Context, resource *v2.Resource) (*stackAccess, error) {
+	projectName, stackName, err := parseStackID(resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators, err := o.client.ListStackCollaborators(ctx, o.orgName, projectName, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	teamPerms, err := o.teamPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stackAccess{
+		Collaborators:   collaborators,
+		TeamPermissions: teamPerms[resource.Id.Resource],
+	}, nil
+}
+
+// Grants returns the direct collaborators and team permissions on a stack. Team grants expand
+// to the team's members. With incremental syncing, the access recorded by the previous sync is
+// used while no event has changed a stack since.
+func (o *stackBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	access := &stackAccess{}
+	if !o.incremental.reuse(ctx, resource, changesStacks, access) {
+		var err error
+		access, err = o.access(ctx, resource)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	var rv []*v2.Grant
+	for _, collaborator := range access.Collaborators {
+		slug, ok := stackPermissionSlugs[collaborator.Permission]
+		if !ok {
+			continue
+		}
+
+		userID, err := o.members.principalID(ctx, collaborator.User)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, batonGrant.NewGrant(
+			resource,
+			slug,
+			&v2.ResourceId{
+				ResourceType: userResourceType.Id,
+				Resource:     userID,
+			},
+			batonGrant.WithGrantMetadata(map[string]interface{}{
+				"source": "collaborator",
+			}),
+		))
+	}
+
+	for _, perm := range access.TeamPermissions {
+		slug, ok := stackPermissionSlugs[perm.Permission]
+		if !ok {
+			continue
+		}
+
+		teamID := &v2.ResourceId{
+			ResourceType: teamResourceType.Id,
+			Resource:     perm.Team,
+		}
+		rv = append(rv, batonGrant.NewGrant(
+			resource,
+			slug,
+			teamID,
+			batonGrant.WithGrantMetadata(map[string]interface{}{
+				"source": "team",
+			}),
+			batonGrant.WithAnnotation(&v2.GrantExpandable{
+				EntitlementIds: []string{batonEntitlement.NewEntitlementID(&v2.Resource{Id: teamID}, entitlementSlugMember)},
+			}),
+		))
+	}
+
+	annos, err := o.incremental.record(access)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, "", annos, nil
+}
+
+// stackPermission returns the stack and Pulumi permission level for a stack entitlement
+func stackPermission(entitlement *v2.Entitlement) (string, string, int, error) {
+	if entitlement == nil || entitlement.Resource == nil || entitlement.Resource.Id == nil {
+		return "", "", 0, fmt.Errorf("entitlement is nil or has nil resource")
+	}
+
+	projectName, stackName, err := parseStackID(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	for permission, slug := range stackPermissionSlugs {
+		if entitlement.Id == batonEntitlement.NewEntitlementID(entitlement.Resource, slug) {
+			return projectName, stackName, permission, nil
+		}
+	}
+	return "", "", 0, fmt.Errorf("unknown stack entitlement ID: %s", entitlement.Id)
+}
+
+// Grant gives a user direct access to a stack or a team access through its team permissions.
+// Pulumi holds a single permission per principal, so granting replaces any other level.
+func (o *stackBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if principal == nil || principal.Id == nil {
+		return nil, nil, fmt.Errorf("principal is nil or has nil id")
+	}
+
+	projectName, stackName, permission, err := stackPermission(entitlement)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch principal.Id.ResourceType {
+	case userResourceType.Id:
+		username, err := o.members.login(ctx, principal.Id.Resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = o.client.SetStackCollaborator(ctx, o.orgName, projectName, stackName, username, permission)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to grant stack permission to user: %w", err)
+		}
+	case teamResourceType.Id:
+		err = o.client.SetTeamStackPermission(ctx, o.orgName, principal.Id.Resource, projectName, stackName, permission)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to grant stack permission to team: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("cannot grant stack permission to resource type: %s", principal.Id.ResourceType)
+	}
+
+	return nil, nil, nil
+}
+
+// Revoke removes a user's direct access to a stack or a team's permission on it
+func (o *stackBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	if grant == nil || grant.Principal == nil || grant.Principal.Id == nil {
+		return nil, fmt.Errorf("grant is nil or has nil principal")
+	}
+
+	projectName, stackName, _, err := stackPermission(grant.Entitlement)
+	if err != nil {
+		return nil, err
+	}
+
+	switch grant.Principal.Id.ResourceType {
+	case userResourceType.Id:
+		username, err := o.members.login(ctx, grant.Principal.Id.Resource)
+		if err != nil {
+			return nil, err
+		}
+		err = o.client.RemoveStackCollaborator(ctx, o.orgName, projectName, stackName, username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke stack permission from user: %w", err)
+		}
+	case teamResourceType.Id:
+		err = o.client.RemoveTeamStackPermission(ctx, o.orgName, grant.Principal.Id.Resource, projectName, stackName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke stack permission from team: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("cannot revoke stack permission from resource type: %s", grant.Principal.Id.ResourceType)
+	}
+
+	return nil, nil
+}
+
+func newStackBuilder(client *client.Client, orgName string, tagFilter *stackTagFilter, members *memberIndex, teams *client.TeamPrefetcher, agentPools *agentPoolIndex, incremental *incrementalSync) *stackBuilder {
+	return &stackBuilder{
+		resourceType: stackResourceType,
+		client:       client,
+		orgName:      orgName,
+		tagFilter:    tagFilter,
+		members:      members,
+		teams:        teams,
+		agentPools:   agentPools,
+		incremental:  incremental,
+	}
+}
@@ -0,0 +1,49 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+)
+
+func TestStackListWithoutDeployments(t *testing.T) {
+	api := newFakeAPI(map[string]http.HandlerFunc{
+		"GET /api/user/stacks": jsonResponse(`{"stacks": [
+			{"orgName": "acme", "projectName": "infra", "stackName": "prod"},
+			{"orgName": "acme", "projectName": "infra", "stackName": "gone"}
+		]}`),
+		"GET /api/stacks/acme/infra/prod":                      jsonResponse(`{"orgName": "acme", "projectName": "infra", "stackName": "prod"}`),
+		"GET /api/stacks/acme/infra/prod/deployments/settings": statusResponse(http.StatusPaymentRequired),
+		"GET /api/orgs/acme/agent-pools":                       statusResponse(http.StatusForbidden),
+	})
+	c := api.client(t)
+	tagFilter, err := newStackTagFilter(nil, nil)
+	if err != nil {
+		t.Fatalf("newStackTagFilter failed: %v", err)
+	}
+	agentPools := newAgentPoolIndex(c, "acme")
+	stacks := newStackBuilder(c, "acme", tagFilter, newMemberIndex(c, "acme", PrincipalKeyLogin), c.NewTeamPrefetcher("acme", 0), agentPools, newIncrementalSync(c, "acme", false))
+	ctx := context.Background()
+
+	project := &v2.ResourceId{ResourceType: projectResourceType.Id, Resource: "infra"}
+	resources, _, _, err := stacks.List(ctx, project, nil)
+	if err != nil {
+		t.Fatalf("stack List failed: %v", err)
+	}
+	if len(resources) != 1 || resources[0].Id.Resource != stackID("infra", "prod") {
+		t.Errorf("stacks = %v, want prod without the deleted stack", resources)
+	}
+
+	pools, _, _, err := newAgentPoolBuilder(c, "acme", agentPools).List(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("agent pool List failed: %v", err)
+	}
+	if len(pools) != 0 {
+		t.Errorf("agent pools = %v, want none without Deployments access", pools)
+	}
+	if n := api.called("GET /api/orgs/acme/agent-pools"); n != 1 {
+		t.Errorf("agent pools listed %d times, want once per sync", n)
+	}
+}
@@ -0,0 +1,136 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+)
+
+// tagTerm matches a stack tag. A term is either "key", matching any stack carrying the tag,
+// or "key=pattern", where pattern may use glob wildcards such as "prod*".
+type tagTerm struct {
+	key     string
+	pattern string
+	anyVal  bool
+}
+
+func parseTagTerm(expr string) (tagTerm, error) {
+	expr = strings.TrimSpace(expr)
+	key, pattern, hasValue := strings.Cut(expr, "=")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return tagTerm{}, fmt.Errorf("invalid stack tag expression %q: missing tag name", expr)
+	}
+	if !hasValue {
+		return tagTerm{key: key, anyVal: true}, nil
+	}
+
+	pattern = strings.TrimSpace(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return tagTerm{}, fmt.Errorf("invalid stack tag expression %q: %w", expr, err)
+	}
+	return tagTerm{key: key, pattern: pattern}, nil
+}
+
+func (t tagTerm) matches(tags map[string]string) bool {
+	value, ok := tags[t.key]
+	if !ok {
+		return false
+	}
+	if t.anyVal {
+		return true
+	}
+	matched, _ := path.Match(t.pattern, value)
+	return matched
+}
+
+// stackTagFilter decides which stacks are synced. A stack is synced when it matches any include
+// term, or there are none, and matches no exclude term.
+type stackTagFilter struct {
+	include []tagTerm
+	exclude []tagTerm
+}
+
+// ValidateStackTagExpressions reports the first malformed stack tag expression
+func ValidateStackTagExpressions(exprs []string) error {
+	_, err := parseTagTerms(exprs)
+	return err
+}
+
+func parseTagTerms(exprs []string) ([]tagTerm, error) {
+	terms := make([]tagTerm, 0, len(exprs))
+	for _, expr := range exprs {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		term, err := parseTagTerm(expr)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func newStackTagFilter(include, exclude []string) (*stackTagFilter, error) {
+	includeTerms, err := parseTagTerms(include)
+	if err != nil {
+		return nil, err
+	}
+	excludeTerms, err := parseTagTerms(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stackTagFilter{
+		include: includeTerms,
+		exclude: excludeTerms,
+	}, nil
+}
+
+// enabled reports whether the filter needs stack tags to make a decision
+func (f *stackTagFilter) enabled() bool {
+	return f != nil && (len(f.include) > 0 || len(f.exclude) > 0)
+}
+
+func (f *stackTagFilter) matches(tags map[string]string) bool {
+	if !f.enabled() {
+		return true
+	}
+
+	for _, term := range f.exclude {
+		if term.matches(tags) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, term := range f.include {
+		if term.matches(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesStack reports whether a stack passes the filter. Stack listings don't carry tags, so
+// they are fetched only when the filter has terms. A stack that no longer exists is not synced.
+func (f *stackTagFilter) includesStack(ctx context.Context, c *client.Client, orgName, projectName, stackName string) (bool, error) {
+	if !f.enabled() {
+		return true, nil
+	}
+
+	stack, err := c.GetStack(ctx, orgName, projectName, stackName)
+	if err != nil {
+		if client.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return f.matches(stack.Tags), nil
+}
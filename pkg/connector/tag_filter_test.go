@@ -0,0 +1,104 @@
+package connector
+
+import (
+	"testing"
+)
+
+func TestParseTagTerm(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    tagTerm
+		wantErr bool
+	}{
+		{expr: "env", want: tagTerm{key: "env", anyVal: true}},
+		{expr: " env = prod* ", want: tagTerm{key: "env", pattern: "prod*"}},
+		{expr: "env=", want: tagTerm{key: "env", pattern: ""}},
+		{expr: "owner=team=infra", want: tagTerm{key: "owner", pattern: "team=infra"}},
+		{expr: "=prod", wantErr: true},
+		{expr: "  ", wantErr: true},
+		{expr: "env=[prod", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTagTerm(tt.expr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseTagTerm(%q) = %+v, want error", tt.expr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTagTerm(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTagTerm(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestStackTagFilterMatches(t *testing.T) {
+	prod := map[string]string{"env": "production", "team": "infra"}
+	dev := map[string]string{"env": "dev"}
+	public := map[string]string{"env": "production", "data-classification": "public"}
+	untagged := map[string]string{}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		tags    map[string]string
+		want    bool
+	}{
+		{name: "no terms", tags: untagged, want: true},
+		{name: "include by key", include: []string{"team"}, tags: prod, want: true},
+		{name: "include by key missing", include: []string{"team"}, tags: dev, want: false},
+		{name: "include by pattern", include: []string{"env=prod*"}, tags: prod, want: true},
+		{name: "include by pattern mismatch", include: []string{"env=prod*"}, tags: dev, want: false},
+		{name: "any include term", include: []string{"env=prod*", "env=dev"}, tags: dev, want: true},
+		{name: "include without tags", include: []string{"env"}, tags: untagged, want: false},
+		{name: "exclude by key", exclude: []string{"data-classification"}, tags: public, want: false},
+		{name: "exclude by pattern mismatch", exclude: []string{"env=dev"}, tags: prod, want: true},
+		{name: "exclude wins over include", include: []string{"env=prod*"}, exclude: []string{"data-classification=public"}, tags: public, want: false},
+		{name: "blank terms are ignored", include: []string{" "}, tags: untagged, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newStackTagFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("newStackTagFilter failed: %v", err)
+			}
+			if got := filter.matches(tt.tags); got != tt.want {
+				t.Errorf("matches(%v) = %v, want %v", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStackTagFilterEnabled(t *testing.T) {
+	var nilFilter *stackTagFilter
+	if nilFilter.enabled() {
+		t.Error("nil filter is enabled")
+	}
+	if !nilFilter.matches(map[string]string{}) {
+		t.Error("nil filter excludes stacks")
+	}
+
+	filter, err := newStackTagFilter(nil, []string{"archived"})
+	if err != nil {
+		t.Fatalf("newStackTagFilter failed: %v", err)
+	}
+	if !filter.enabled() {
+		t.Error("filter with an exclude term is not enabled")
+	}
+}
+
+func TestValidateStackTagExpressions(t *testing.T) {
+	if err := ValidateStackTagExpressions([]string{"env=prod*", "team", ""}); err != nil {
+		t.Errorf("valid expressions rejected: %v", err)
+	}
+	if err := ValidateStackTagExpressions([]string{"env", "=prod"}); err == nil {
+		t.Error("expression without a tag name accepted")
+	}
+}
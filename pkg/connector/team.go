@@ -3,6 +3,8 @@ package connector
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -12,22 +14,40 @@ import (
 	batonEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	batonGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type teamBuilder struct {
 	resourceType *v2.ResourceType
 	client       *client.Client
 	orgName      string
+	members      *memberIndex
+	scim         *scimGuard
+	teams        *client.TeamPrefetcher
+	incremental  *incrementalSync
+	saml         *samlIndex
 }
 
+const (
+	teamKindPulumi         = "pulumi"
+	membershipSourceSAML   = "saml"
+	membershipSourcePulumi = "pulumi"
+)
+
 var _ connectorbuilder.ResourceSyncer = &teamBuilder{}
 var _ connectorbuilder.ResourceProvisionerV2 = &teamBuilder{}
 
-func teamResource(team client.Team, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+func teamResource(team client.Team, parentResourceId *v2.ResourceId, idpGroups []string) (*v2.Resource, error) {
 	profile := map[string]interface{}{
-		"name":         team.Name,
-		"display_name": team.DisplayName,
-		"description":  team.Description,
+		"name":              team.Name,
+		"display_name":      team.DisplayName,
+		"description":       team.Description,
+		"membership_source": teamMembershipSource(team, idpGroups),
+	}
+	if len(idpGroups) > 0 {
+		profile["idp_groups"] = strings.Join(idpGroups, ", ")
 	}
 
 	return batonResource.NewGroupResource(
@@ -38,9 +58,77 @@ func teamResource(team client.Team, parentResourceId *v2.ResourceId) (*v2.Resour
 			batonResource.WithGroupProfile(profile),
 		},
 		batonResource.WithParentResourceID(parentResourceId),
+		batonResource.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: accessTokenResourceType.Id}),
 	)
 }
 
+// teamMembershipSource returns where a team's membership is managed: SAML group mappings,
+// a version control provider for GitHub or GitLab backed teams, or Pulumi itself
+func teamMembershipSource(team client.Team, idpGroups []string) string {
+	switch {
+	case len(idpGroups) > 0:
+		return membershipSourceSAML
+	case team.Kind != "" && team.Kind != teamKindPulumi:
+		return team.Kind
+	default:
+		return membershipSourcePulumi
+	}
+}
+
+// samlIndex keeps the organization's SAML configuration for the rest of a sync, so that team
+// grants resolve IdP groups without reading it for every team.
+type samlIndex struct {
+	client  *client.Client
+	orgName string
+
+	mu     sync.Mutex
+	config *client.SAMLConfig
+	loaded bool
+}
+
+func newSAMLIndex(client *client.Client, orgName string) *samlIndex {
+	return &samlIndex{
+		client:  client,
+		orgName: orgName,
+	}
+}
+
+// get returns the organization's SAML configuration. It is reloaded when teams are listed so
+// that each sync sees the current group mappings. A token that may not read it is treated as if
+// SAML were not configured, so teams still sync without IdP group details.
+func (s *samlIndex) get(ctx context.Context, reload bool) (*client.SAMLConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loaded && !reload {
+		return s.config, nil
+	}
+
+	config, err := s.client.GetSAMLConfig(ctx, s.orgName)
+	switch {
+	case client.IsPermissionDenied(err):
+		ctxzap.Extract(ctx).Warn("token cannot read the SAML configuration, treating SAML as not configured", zap.Error(err))
+		config = nil
+	case err != nil:
+		return nil, err
+	case config == nil:
+		ctxzap.Extract(ctx).Debug("SAML is not configured")
+	}
+	s.config = config
+	s.loaded = true
+
+	return config, nil
+}
+
+// reset drops the SAML configuration, so that the next sync reads it again
+func (s *samlIndex) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config = nil
+	s.loaded = false
+}
+
 func (o *teamBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return teamResourceType
 }
@@ -63,8 +151,16 @@ func (o *teamBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId,
 		return nil, "", annotations, fmt.Errorf("failed to list teams: %w", err)
 	}
 
+	// Team details are prefetched by the first Grants call, so a new listing drops the old ones
+	o.teams.Reset()
+
+	saml, err := o.saml.get(ctx, true)
+	if err != nil {
+		return nil, "", annotations, err
+	}
+
 	for _, team := range resp {
-		teamResource, err := teamResource(team, parentResourceID)
+		teamResource, err := teamResource(team, parentResourceID, saml.GroupsForTeam(team.Name))
 		if err != nil {
 			return nil, "", annotations, err
 		}
@@ -80,7 +176,8 @@ func (o *teamBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *
 	memberEnt := batonEntitlement.NewAssignmentEntitlement(
 		resource,
 		"member",
-		batonEntitlement.WithGrantableTo(userResourceType),
+		// An OIDC issuer is a member when its policy issues tokens for the team
+		batonEntitlement.WithGrantableTo(userResourceType, oidcIssuerResourceType),
 		batonEntitlement.WithDescription("Member of the team"),
 		batonEntitlement.WithDisplayName("Member"),
 	)
@@ -88,30 +185,77 @@ func (o *teamBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *
 	return []*v2.Entitlement{memberEnt}, "", nil, nil
 }
 
-// Grants returns the granted entitlements for users in the team.
+// Grants returns the granted entitlements for users in the team. With incremental syncing, the
+// team details recorded by the previous sync are used while no event has changed a team since.
 func (o *teamBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	var rv []*v2.Grant
 	var annotations annotations.Annotations
 
 	// Get team details including members
-	team, err := o.client.GetTeam(ctx, o.orgName, resource.Id.Resource)
+	team := &client.Team{}
+	if !o.incremental.reuse(ctx, resource, changesTeams, team) {
+		var err error
+		team, err = o.teams.GetTeam(ctx, resource.Id.Resource)
+		if client.IsNotFound(err) {
+			// The team was deleted since it was listed
+			return nil, "", annotations, nil
+		}
+		if err != nil {
+			return nil, "", annotations, fmt.Errorf("failed to get team: %w", err)
+		}
+	}
+
+	saml, err := o.saml.get(ctx, false)
 	if err != nil {
-		return nil, "", annotations, fmt.Errorf("failed to get team: %w", err)
+		return nil, "", annotations, err
+	}
+
+	// Memberships driven by the identity provider or a VCS provider must be changed there
+	var grantOpts []batonGrant.GrantOption
+	idpGroups := saml.GroupsForTeam(team.Name)
+	if source := teamMembershipSource(*team, idpGroups); source != membershipSourcePulumi {
+		metadata := map[string]interface{}{
+			"managed_by": source,
+		}
+		if len(idpGroups) > 0 {
+			metadata["idp_groups"] = strings.Join(idpGroups, ", ")
+		}
+
+		md, err := structpb.NewStruct(metadata)
+		if err != nil {
+			return nil, "", annotations, fmt.Errorf("failed to build grant metadata: %w", err)
+		}
+		grantOpts = append(grantOpts, batonGrant.WithGrantMetadata(metadata))
+		// With --scim-override SCIM-pushed memberships can be revoked here, so they aren't immutable
+		if source != membershipSourceSCIM || !o.scim.allowOverride {
+			grantOpts = append(grantOpts, batonGrant.WithAnnotation(&v2.GrantImmutable{SourceId: source, Metadata: md}))
+		}
 	}
 
 	for _, member := range team.Members {
+		userID, err := o.members.principalID(ctx, member)
+		if err != nil {
+			return nil, "", annotations, err
+		}
+
 		grant := batonGrant.NewGrant(
 			resource,
 			"member",
 			&v2.ResourceId{
 				ResourceType: userResourceType.Id,
-				Resource:     member.GithubLogin,
+				Resource:     userID,
 			},
+			grantOpts...,
 		)
 
 		rv = append(rv, grant)
 	}
 
+	annotations, err = o.incremental.record(team)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
 	return rv, "", annotations, nil
 }
 
@@ -127,7 +271,12 @@ func (o *teamBuilder) Grant(ctx context.Context, principal *v2.Resource, entitle
 		return nil, nil, fmt.Errorf("cannot grant team membership to non-user resource type: %s", principal.Id.ResourceType)
 	}
 
-	err := o.client.UpdateTeamMembership(ctx, o.orgName, entitlement.Resource.Id.Resource, principal.Id.Resource, "add")
+	username, err := o.members.login(ctx, principal.Id.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = o.client.UpdateTeamMembership(ctx, o.orgName, entitlement.Resource.Id.Resource, username, "add")
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to add team member: %w", err)
 	}
@@ -143,8 +292,26 @@ func (o *teamBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.
 	if grant.Entitlement == nil || grant.Entitlement.Resource == nil || grant.Entitlement.Resource.Id == nil {
 		return nil, fmt.Errorf("grant has nil entitlement or resource")
 	}
+	if grant.Principal.Id.ResourceType != userResourceType.Id {
+		return nil, fmt.Errorf("cannot revoke team membership from non-user resource type: %s", grant.Principal.Id.ResourceType)
+	}
+
+	teamName := grant.Entitlement.Resource.Id.Resource
+	username, err := o.members.login(ctx, grant.Principal.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := o.client.GetTeam(ctx, o.orgName, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	scimManaged := o.scim.teamIsManaged(ctx, *team)
+	if err := o.scim.checkRevoke(ctx, scimManaged, fmt.Sprintf("membership of %s in team %s", username, teamName)); err != nil {
+		return nil, err
+	}
 
-	err := o.client.UpdateTeamMembership(ctx, o.orgName, grant.Entitlement.Resource.Id.Resource, grant.Principal.Id.Resource, "remove")
+	err = o.client.UpdateTeamMembership(ctx, o.orgName, teamName, username, "remove")
 	if err != nil {
 		return nil, fmt.Errorf("failed to remove team member: %w", err)
 	}
@@ -152,10 +319,15 @@ func (o *teamBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.
 	return nil, nil
 }
 
-func newTeamBuilder(client *client.Client, orgName string) *teamBuilder {
+func newTeamBuilder(client *client.Client, orgName string, members *memberIndex, scim *scimGuard, teams *client.TeamPrefetcher, incremental *incrementalSync, saml *samlIndex) *teamBuilder {
 	return &teamBuilder{
 		resourceType: teamResourceType,
 		client:       client,
 		orgName:      orgName,
+		members:      members,
+		scim:         scim,
+		teams:        teams,
+		incremental:  incremental,
+		saml:         saml,
 	}
 }
@@ -0,0 +1,73 @@
+package connector
+
+import (
+	"fmt"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultTokenStaleDays is how many days a token may go unused before it is marked stale
+const DefaultTokenStaleDays = 90
+
+// tokenStaleness marks access tokens that have not been used for a while. A token that was never
+// used counts as unused since it was created.
+type tokenStaleness struct {
+	after time.Duration
+	now   func() time.Time
+}
+
+func newTokenStaleness(days int) *tokenStaleness {
+	return &tokenStaleness{
+		after: time.Duration(days) * 24 * time.Hour,
+		now:   time.Now,
+	}
+}
+
+// options records a token's staleness in the profile of its secret trait: whether it is stale,
+// the threshold, and for stale tokens how long they have been unused. Stale tokens are also
+// described as such. The description only names the threshold, so it stays the same from one
+// sync to the next.
+func (s *tokenStaleness) options(created, lastUsed int64) ([]batonResource.SecretTraitOption, []batonResource.ResourceOption, error) {
+	if s == nil || s.after <= 0 {
+		return nil, nil, nil
+	}
+
+	since, ok := unixTimestamp(lastUsed)
+	if !ok {
+		if since, ok = unixTimestamp(created); !ok {
+			return nil, nil, nil
+		}
+	}
+
+	unused := s.now().Sub(since)
+	staleAfterDays := int(s.after / (24 * time.Hour))
+	fields := map[string]interface{}{
+		"stale":            unused >= s.after,
+		"stale_after_days": staleAfterDays,
+	}
+
+	var resourceOpts []batonResource.ResourceOption
+	if unused >= s.after {
+		fields["days_unused"] = int(unused / (24 * time.Hour))
+		fields["unused_since"] = since.Format(time.RFC3339)
+		resourceOpts = append(resourceOpts, batonResource.WithDescription(fmt.Sprintf("Stale: unused for more than %d days", staleAfterDays)))
+	}
+
+	profile, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build token profile: %w", err)
+	}
+
+	return []batonResource.SecretTraitOption{withSecretProfile(profile)}, resourceOpts, nil
+}
+
+// withSecretProfile sets the profile of a secret trait, which the SDK has no option for
+func withSecretProfile(profile *structpb.Struct) batonResource.SecretTraitOption {
+	return func(t *v2.SecretTrait) error {
+		t.Profile = profile
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// stalenessResource builds a token resource with the staleness options for the given timestamps
+func stalenessResource(t *testing.T, s *tokenStaleness, created, lastUsed int64) (*v2.Resource, *v2.SecretTrait) {
+	t.Helper()
+
+	traitOpts, opts, err := s.options(created, lastUsed)
+	if err != nil {
+		t.Fatalf("options failed: %v", err)
+	}
+	resource, err := batonResource.NewSecretResource("token", accessTokenResourceType, "token-1", traitOpts, opts...)
+	if err != nil {
+		t.Fatalf("NewSecretResource failed: %v", err)
+	}
+
+	trait := &v2.SecretTrait{}
+	annos := annotations.Annotations(resource.Annotations)
+	if ok, err := annos.Pick(trait); err != nil || !ok {
+		t.Fatalf("secret trait missing: %v", err)
+	}
+	return resource, trait
+}
+
+func TestTokenStaleness(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) int64 { return now.Add(-time.Duration(days) * 24 * time.Hour).Unix() }
+
+	tests := []struct {
+		name      string
+		staleDays int
+		created   int64
+		lastUsed  int64
+		wantStale bool
+		wantDays  float64
+	}{
+		{"recently used", 90, daysAgo(400), daysAgo(10), false, 0},
+		{"used long ago", 90, daysAgo(400), daysAgo(120), true, 120},
+		{"used exactly at the threshold", 90, daysAgo(400), daysAgo(90), true, 90},
+		{"never used and new", 90, daysAgo(30), 0, false, 0},
+		{"never used and old", 90, daysAgo(100), 0, true, 100},
+		{"no timestamps", 90, 0, 0, false, 0},
+		{"disabled", 0, daysAgo(400), daysAgo(400), false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTokenStaleness(tt.staleDays)
+			s.now = func() time.Time { return now }
+
+			resource, trait := stalenessResource(t, s, tt.created, tt.lastUsed)
+			profile := trait.GetProfile()
+			if tt.staleDays == 0 || (tt.created == 0 && tt.lastUsed == 0) {
+				if profile != nil {
+					t.Errorf("profile = %v, want none", profile)
+				}
+				return
+			}
+
+			if got := profile.Fields["stale"].GetBoolValue(); got != tt.wantStale {
+				t.Errorf("stale = %v, want %v", got, tt.wantStale)
+			}
+			if got := profile.Fields["stale_after_days"].GetNumberValue(); got != float64(tt.staleDays) {
+				t.Errorf("stale_after_days = %v, want %d", got, tt.staleDays)
+			}
+			if !tt.wantStale {
+				if resource.Description != "" {
+					t.Errorf("description = %q, want none", resource.Description)
+				}
+				if _, ok := profile.Fields["days_unused"]; ok {
+					t.Errorf("days_unused set on a token that is not stale")
+				}
+				return
+			}
+
+			if want := "Stale: unused for more than 90 days"; resource.Description != want {
+				t.Errorf("description = %q, want %q", resource.Description, want)
+			}
+			if got := profile.Fields["days_unused"].GetNumberValue(); got != tt.wantDays {
+				t.Errorf("days_unused = %v, want %v", got, tt.wantDays)
+			}
+			if profile.Fields["unused_since"].GetStringValue() == "" {
+				t.Errorf("unused_since missing")
+			}
+		})
+	}
+}
+
+func TestTokenStalenessDescriptionIsStable(t *testing.T) {
+	s := newTokenStaleness(30)
+	lastUsed := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var descriptions []string
+	for _, days := range []int{40, 41, 200} {
+		s.now = func() time.Time { return lastUsed.Add(time.Duration(days) * 24 * time.Hour) }
+		resource, _ := stalenessResource(t, s, lastUsed.Unix(), lastUsed.Unix())
+		descriptions = append(descriptions, resource.Description)
+	}
+
+	for _, description := range descriptions[1:] {
+		if description != descriptions[0] {
+			t.Errorf("description changed from %q to %q as the token aged", descriptions[0], description)
+		}
+	}
+}
@@ -3,20 +3,30 @@ package connector
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
 type userBuilder struct {
-	client  *client.Client
-	orgName string
+	client       *client.Client
+	orgName      string
+	principalKey string
+	scim         *scimGuard
 }
 
-func userResource(user *client.User, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+var _ connectorbuilder.AccountManager = &userBuilder{}
+
+// userResource builds a user resource. tokenOwner marks the user whose personal token the connector
+// uses, whose personal tokens are synced as children.
+func userResource(user *client.User, parentResourceId *v2.ResourceId, principalKey string, scimManaged bool, tokenOwner bool) (*v2.Resource, error) {
 	if user == nil {
 		return nil, fmt.Errorf("user is nil")
 	}
@@ -26,31 +36,100 @@ func userResource(user *client.User, parentResourceId *v2.ResourceId) (*v2.Resou
 		"name":         user.User.Name,
 		"role":         user.Role,
 	}
+	if user.User.ID != "" {
+		profile["user_id"] = user.User.ID
+	}
+	if user.User.Email != "" {
+		profile["email"] = user.User.Email
+	}
+	if provider := identityProvider(user.User); provider != "" {
+		profile["identity_provider"] = provider
+	}
+	if scimManaged {
+		profile["managed_by"] = membershipSourceSCIM
+	}
 
-	userStatus := v2.UserTrait_Status_STATUS_ENABLED
+	userStatus, statusDetails := memberStatus(user)
 	accountType := v2.UserTrait_ACCOUNT_TYPE_HUMAN
+	if user.User.IsMachineUser {
+		accountType = v2.UserTrait_ACCOUNT_TYPE_SERVICE
+	}
 
 	userTraits := []batonResource.UserTraitOption{
 		batonResource.WithUserLogin(user.User.GithubLogin),
-		batonResource.WithUserProfile(profile),
-		batonResource.WithStatus(userStatus),
+		batonResource.WithEmail(user.User.Email, true),
+		batonResource.WithDetailedStatus(userStatus, statusDetails),
 		batonResource.WithAccountType(accountType),
 	}
 
+	if created, ok := parseTimestamp(user.Created); ok {
+		profile["created"] = created.Format(time.RFC3339)
+		userTraits = append(userTraits, batonResource.WithCreatedAt(created))
+	}
+	if lastLogin, ok := parseTimestamp(user.LastLogin); ok {
+		profile["last_login"] = lastLogin.Format(time.RFC3339)
+		userTraits = append(userTraits, batonResource.WithLastLogin(lastLogin))
+	}
+	userTraits = append(userTraits, batonResource.WithUserProfile(profile))
+
+	if icon := avatarAssetRef(user.User); icon != nil {
+		userTraits = append(userTraits, batonResource.WithUserIcon(icon))
+	}
+
 	name := user.User.Name
 	if name == "" {
 		name = user.User.GithubLogin
 	}
 
+	opts := []batonResource.ResourceOption{batonResource.WithParentResourceID(parentResourceId)}
+	if tokenOwner {
+		opts = append(opts, batonResource.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: accessTokenResourceType.Id}))
+	}
+
 	return batonResource.NewUserResource(
 		name,
 		userResourceType,
-		user.User.GithubLogin,
+		userPrincipalID(user.User, principalKey),
 		userTraits,
-		batonResource.WithParentResourceID(parentResourceId),
+		opts...,
 	)
 }
 
+// memberStatus derives the user status from the member's SSO, SCIM and sign-up state
+func memberStatus(user *client.User) (v2.UserTrait_Status_Status, string) {
+	switch {
+	case user.Deprovisioned:
+		return v2.UserTrait_Status_STATUS_DELETED, "deprovisioned by SCIM"
+	case user.Suspended:
+		return v2.UserTrait_Status_STATUS_DISABLED, "suspended by SSO"
+	case !user.KnownToPulumi:
+		return v2.UserTrait_Status_STATUS_DISABLED, "has not signed in to Pulumi Cloud"
+	default:
+		return v2.UserTrait_Status_STATUS_ENABLED, ""
+	}
+}
+
+// parseTimestamp parses the RFC 3339 timestamps returned by the Pulumi API
+func parseTimestamp(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// unixTimestamp converts the Unix timestamps returned by the Pulumi API, treating zero as unset
+func unixTimestamp(value int64) (time.Time, bool) {
+	if value <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(value, 0).UTC(), true
+}
+
 func (ub *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return userResourceType
 }
@@ -73,9 +152,21 @@ func (ub *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId
 		return nil, "", nil, fmt.Errorf("failed to list users: %w", err)
 	}
 
+	// Refresh the SCIM status at the start of each sync
+	ub.scim.isEnabled(ctx, token == "")
+
+	// Personal tokens are only synced for the user whose token the connector uses
+	owner, err := personalTokenOwner(ctx, ub.client)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("failed to look up the access token owner, skipping personal tokens", zap.Error(err))
+	}
+
 	resources := make([]*v2.Resource, 0, len(resp.Members))
 	for _, member := range resp.Members {
-		resource, err := userResource(&member, orgParentID)
+		scimManaged := ub.scim.memberIsManaged(ctx, member)
+		tokenOwner := owner != "" && member.User.GithubLogin == owner
+
+		resource, err := userResource(&member, orgParentID, ub.principalKey, scimManaged, tokenOwner)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -95,9 +186,71 @@ func (ub *userBuilder) Grants(_ context.Context, resource *v2.Resource, _ *pagin
 	return nil, "", nil, nil
 }
 
-func newUserBuilder(client *client.Client, orgName string) *userBuilder {
+// CreateAccount invites a user to the organization. The user only becomes a member once they
+// accept the invite, so the result asks for that action instead of returning a resource.
+func (ub *userBuilder) CreateAccount(ctx context.Context, accountInfo *v2.AccountInfo, _ *v2.CredentialOptions) (
+	connectorbuilder.CreateAccountResponse,
+	[]*v2.PlaintextData,
+	annotations.Annotations,
+	error,
+) {
+	profile := accountInfo.GetProfile().AsMap()
+
+	email, _ := profile["email"].(string)
+	if email == "" {
+		for _, e := range accountInfo.GetEmails() {
+			if e.GetIsPrimary() || email == "" {
+				email = e.GetAddress()
+			}
+		}
+	}
+	if email == "" {
+		return nil, nil, nil, fmt.Errorf("an email address is required to invite a user")
+	}
+
+	role, _ := profile["role"].(string)
+	switch role {
+	case "":
+		role = roleMember
+	case roleMember, roleAdmin:
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid role %q: must be %q or %q", role, roleMember, roleAdmin)
+	}
+
+	var teams []string
+	if values, ok := profile["teams"].([]interface{}); ok {
+		for _, value := range values {
+			if team, ok := value.(string); ok && team != "" {
+				teams = append(teams, team)
+			}
+		}
+	}
+
+	if err := ub.client.InviteUser(ctx, ub.orgName, email, role, teams); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &v2.CreateAccountResponse_ActionRequiredResult{
+		Message:               fmt.Sprintf("Invited %s to organization %s; they become a member once they accept the invite.", email, ub.orgName),
+		IsCreateAccountResult: true,
+	}, nil, nil, nil
+}
+
+// CreateAccountCapabilityDetails reports that invited users sign in with their own credentials
+func (ub *userBuilder) CreateAccountCapabilityDetails(_ context.Context) (*v2.CredentialDetailsAccountProvisioning, annotations.Annotations, error) {
+	return &v2.CredentialDetailsAccountProvisioning{
+		SupportedCredentialOptions: []v2.CapabilityDetailCredentialOption{
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_NO_PASSWORD,
+		},
+		PreferredCredentialOption: v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_NO_PASSWORD,
+	}, nil, nil
+}
+
+func newUserBuilder(client *client.Client, orgName, principalKey string, scim *scimGuard) *userBuilder {
 	return &userBuilder{
-		client:  client,
-		orgName: orgName,
+		client:       client,
+		orgName:      orgName,
+		principalKey: principalKey,
+		scim:         scim,
 	}
 }
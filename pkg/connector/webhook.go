@@ -0,0 +1,204 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/conductorone/baton-pulumi-cloud/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	batonResource "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// webhookFormats maps Pulumi webhook formats to display names
+var webhookFormats = map[string]string{
+	"raw":                "Generic",
+	"slack":              "Slack",
+	"ms_teams":           "Microsoft Teams",
+	"pulumi_deployments": "Pulumi Deployments",
+}
+
+type webhookBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.Client
+	orgName      string
+	tagFilter    *stackTagFilter
+}
+
+var _ connectorbuilder.ResourceSyncer = &webhookBuilder{}
+var _ connectorbuilder.ResourceDeleter = &webhookBuilder{}
+
+// webhookID returns the resource ID of a webhook: the hook name for organization webhooks,
+// and project/stack/name for stack webhooks
+func webhookID(hook client.Webhook) string {
+	if hook.StackName == "" {
+		return hook.Name
+	}
+	return strings.Join([]string{hook.ProjectName, hook.StackName, hook.Name}, "/")
+}
+
+// webhookDestination reduces a payload URL to its scheme and host. Payload URLs often carry
+// secrets in their path or query, such as Slack and Teams incoming-webhook tokens.
+func webhookDestination(payloadURL string) string {
+	u, err := url.Parse(payloadURL)
+	if err != nil || u.Host == "" {
+		return "(unparseable URL)"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func webhookResource(hook client.Webhook, parentResourceId *v2.ResourceId) (*v2.Resource, error) {
+	format := hook.Format
+	if name, ok := webhookFormats[hook.Format]; ok {
+		format = name
+	}
+
+	scope := "organization"
+	if hook.StackName != "" {
+		scope = "stack"
+	}
+
+	destination := webhookDestination(hook.PayloadURL)
+
+	profile := map[string]interface{}{
+		"name":        hook.Name,
+		"payload_url": destination,
+		"format":      format,
+		"filters":     strings.Join(hook.Filters, ", "),
+		"active":      hook.Active,
+		"scope":       scope,
+	}
+	if hook.StackName != "" {
+		profile["project"] = hook.ProjectName
+		profile["stack"] = hook.StackName
+	}
+
+	displayName := hook.DisplayName
+	if displayName == "" {
+		displayName = hook.Name
+	}
+
+	return batonResource.NewAppResource(
+		displayName,
+		webhookResourceType,
+		webhookID(hook),
+		[]batonResource.AppTraitOption{
+			batonResource.WithAppProfile(profile),
+		},
+		batonResource.WithParentResourceID(parentResourceId),
+		batonResource.WithDescription(fmt.Sprintf("%s webhook delivering to %s", format, destination)),
+	)
+}
+
+func (o *webhookBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return webhookResourceType
+}
+
+// List returns the organization webhooks followed by the webhooks of each stack, one page of stacks at a time.
+func (o *webhookBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	var token string
+	if pToken != nil {
+		token = pToken.Token
+	}
+
+	orgParentID := &v2.ResourceId{
+		ResourceType: orgResourceType.Id,
+		Resource:     o.orgName,
+	}
+
+	var hooks []client.Webhook
+	if token == "" {
+		orgHooks, err := o.client.ListOrgWebhooks(ctx, o.orgName)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to list organization webhooks: %w", err)
+		}
+		hooks = append(hooks, orgHooks...)
+	}
+
+	resp, err := o.client.ListStacks(ctx, o.orgName, token)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	for _, stack := range resp.Stacks {
+		included, err := o.tagFilter.includesStack(ctx, o.client, o.orgName, stack.ProjectName, stack.StackName)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !included {
+			continue
+		}
+
+		stackHooks, err := o.client.ListStackWebhooks(ctx, o.orgName, stack.ProjectName, stack.StackName)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to list webhooks for stack %s/%s: %w", stack.ProjectName, stack.StackName, err)
+		}
+		for _, hook := range stackHooks {
+			hook.ProjectName = stack.ProjectName
+			hook.StackName = stack.StackName
+			hooks = append(hooks, hook)
+		}
+	}
+
+	resources := make([]*v2.Resource, 0, len(hooks))
+	for _, hook := range hooks {
+		resource, err := webhookResource(hook, orgParentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, resp.ContinuationToken, nil, nil
+}
+
+// Entitlements returns an empty list since webhooks don't have entitlements
+func (o *webhookBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns an empty list since webhooks don't have entitlements
+func (o *webhookBuilder) Grants(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Delete removes an organization or stack webhook
+func (o *webhookBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId == nil || resourceId.Resource == "" {
+		return nil, fmt.Errorf("webhook resource id is empty")
+	}
+	if resourceId.ResourceType != webhookResourceType.Id {
+		return nil, fmt.Errorf("cannot delete non-webhook resource type: %s", resourceId.ResourceType)
+	}
+
+	parts := strings.Split(resourceId.Resource, "/")
+	switch len(parts) {
+	case 1:
+		err := o.client.DeleteOrgWebhook(ctx, o.orgName, parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete organization webhook: %w", err)
+		}
+	case 3:
+		err := o.client.DeleteStackWebhook(ctx, o.orgName, parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete stack webhook: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid webhook resource id: %s", resourceId.Resource)
+	}
+
+	return nil, nil
+}
+
+func newWebhookBuilder(client *client.Client, orgName string, tagFilter *stackTagFilter) *webhookBuilder {
+	return &webhookBuilder{
+		resourceType: webhookResourceType,
+		client:       client,
+		orgName:      orgName,
+		tagFilter:    tagFilter,
+	}
+}